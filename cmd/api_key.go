@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/database/repository"
+)
+
+// APIKey runs an api-key subcommand.
+//
+//	api-key create pos products:read transactions:read transactions:write
+//	api-key list
+//	api-key revoke 1
+func APIKey(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing subcommand: create, list or revoke")
+	}
+
+	switch args[0] {
+	case "create":
+		if len(args) < 3 {
+			return fmt.Errorf("usage: api-key create <name> <scope>...\nScopes: %s", strings.Join(repository.APIScopes, ", "))
+		}
+		key, apiKey, err := repository.CreateAPIKey(ctx, args[1], args[2:])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("API key %d (%s) created with scopes: %s\n", apiKey.ID, apiKey.Name, apiKey.Scopes)
+		fmt.Println("Key:", key)
+		fmt.Println("Store it now, it can't be shown again.")
+		return nil
+	case "list":
+		apiKeys, err := database.Sqlc.ListApiKeys(ctx)
+		if err != nil {
+			return err
+		}
+		for _, k := range apiKeys {
+			status := "active"
+			if k.RevokedAt.Valid {
+				status = "revoked"
+			}
+			lastUsed := "never"
+			if k.LastUsedAt.Valid {
+				lastUsed = k.LastUsedAt.Time.Format("2006-01-02 15:04")
+			}
+			fmt.Printf("%4d  %-16s %-10s %-8s used %-16s %s\n", k.ID, k.Name, k.Prefix+"…", status, lastUsed, k.Scopes)
+		}
+		return nil
+	case "revoke":
+		if len(args) < 2 {
+			return errors.New("usage: api-key revoke <id>")
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid id %q", args[1])
+		}
+		err = repository.RevokeAPIKey(ctx, id)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("API key %d revoked\n", id)
+		return nil
+	default:
+		return fmt.Errorf("unknown subcommand '%s'", args[0])
+	}
+}
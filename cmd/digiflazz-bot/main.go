@@ -8,8 +8,10 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"slices"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/fidrasofyan/digiflazz-bot/cmd"
 	"github.com/fidrasofyan/digiflazz-bot/database"
@@ -30,8 +32,14 @@ func main() {
 
 	mainCtx, cancel := context.WithCancel(context.Background())
 
+	// "start --polling" is a shortcut for TELEGRAM_MODE=polling
+	if os.Args[1] == "start" && slices.Contains(os.Args[2:], "--polling") {
+		os.Setenv("TELEGRAM_MODE", "polling")
+	}
+
 	// Load config
 	config.MustLoadConfig()
+	service.MustLoadClients()
 
 	// Setup signal catching
 	quitCh := make(chan os.Signal, 1)
@@ -44,13 +52,15 @@ func main() {
 	errCh := make(chan error, 1)
 
 	var httpServer *fiber.App
+	var telegramPolling *cmd.TelegramPolling
 
 	switch os.Args[1] {
 	case "start":
 		go func() {
 			log.Printf(
-				"Environment: %s - Runtime: %s - App: %s - TZ: %s\n",
+				"Environment: %s - Mode: %s - Runtime: %s - App: %s - TZ: %s\n",
 				config.Cfg.AppEnv,
+				config.Cfg.TelegramMode,
 				runtime.Version(),
 				AppVersion,
 				os.Getenv("TZ"),
@@ -59,23 +69,48 @@ func main() {
 			// Load database
 			database.MustLoadDatabase(mainCtx)
 
-			// Only in production
-			if config.Cfg.AppEnv == "production" {
-				// Set webhook
-				err := cmd.SetTelegramWebhookAndCommands(mainCtx)
+			// Polling mode: no public URL, Telegram updates are polled instead
+			if config.Cfg.TelegramMode == "polling" {
+				// The webhook setup below is skipped, so set the per-role
+				// command menus here
+				err := cmd.SetTelegramCommands(mainCtx)
 				if err != nil {
 					errCh <- err
 					return
 				}
+				telegramPolling, err = cmd.StartTelegramPolling(mainCtx)
+				if err != nil {
+					errCh <- err
+					return
+				}
+			}
+
+			// Only in production
+			if config.Cfg.AppEnv == "production" {
+				// Set webhook
+				if config.Cfg.TelegramMode == "webhook" {
+					err := cmd.SetTelegramWebhookAndCommands(mainCtx)
+					if err != nil {
+						errCh <- err
+						return
+					}
+				}
 
 				// Populate products
-				err = job.PopulateProducts(mainCtx)
+				err := job.PopulateProducts(mainCtx)
 				if err != nil {
 					errCh <- err
 					return
 				}
 			}
 
+			// Periodic jobs
+			// Pending transactions are polled in webhook mode too, for those
+			// whose request to Digiflazz failed before it answered
+			job.RunPeriodically(mainCtx, "PollPendingTransactions", config.Cfg.TrxStatusPollInterval, job.PollPendingTransactions)
+			job.RunPeriodically(mainCtx, "CleanupTelegramUpdates", 1*time.Hour, job.CleanupTelegramUpdates)
+			job.RunPeriodically(mainCtx, "CleanupExpiredChats", 1*time.Minute, job.CleanupExpiredChats)
+
 			// Start HTTP server
 			httpServer = cmd.MustStartHTTPServer()
 		}()
@@ -103,12 +138,55 @@ func main() {
 			quitCh <- syscall.SIGQUIT
 		}()
 
+	case "print-receipt":
+		go func() {
+			// Load database
+			database.MustLoadDatabase(mainCtx)
+
+			err := cmd.PrintReceipt(mainCtx, os.Args[2:])
+			if err != nil {
+				errCh <- err
+				return
+			}
+			quitCh <- syscall.SIGQUIT
+		}()
+
+	case "ledger":
+		go func() {
+			// Load database
+			database.MustLoadDatabase(mainCtx)
+
+			err := cmd.Ledger(mainCtx, os.Args[2:])
+			if err != nil {
+				errCh <- err
+				return
+			}
+			quitCh <- syscall.SIGQUIT
+		}()
+
+	case "api-key":
+		go func() {
+			// Load database
+			database.MustLoadDatabase(mainCtx)
+
+			err := cmd.APIKey(mainCtx, os.Args[2:])
+			if err != nil {
+				errCh <- err
+				return
+			}
+			quitCh <- syscall.SIGQUIT
+		}()
+
 	case "digiflazz-sign":
 		if len(os.Args) < 3 {
 			errCh <- errors.New("missing second argument")
 			return
 		}
-		sign := service.DigiflazzSign(os.Args[2])
+		digiflazz := service.NewDigiflazzClient(&service.DigiflazzClientParams{
+			Username: config.Cfg.DigiflazzUsername,
+			ApiKey:   config.Cfg.DigiflazzApiKey,
+		})
+		sign := digiflazz.Sign(os.Args[2])
 		fmt.Println("Sign:", sign)
 		quitCh <- syscall.SIGQUIT
 
@@ -133,9 +211,18 @@ func main() {
 
 	case "help":
 		help := []string{
-			"start                          Start the bot",
+			"start [--polling]              Start the bot (--polling: use getUpdates instead of webhook)",
 			"set-telegram-webhook           Set Telegram webhook and commands",
 			"populate-products              Populate products",
+			"print-receipt <ref_id> --device <path> | --output <file|->",
+			"                               Print a receipt on an ESC/POS thermal printer",
+			"ledger verify                  Recompute ledger balances and report inconsistencies",
+			"ledger adjust <account> <amount> [memo]",
+			"                               Adjust a ledger account against equity",
+			"api-key create <name> <scope>...",
+			"                               Create a REST API key",
+			"api-key list                   List REST API keys",
+			"api-key revoke <id>            Revoke a REST API key",
 			"digiflazz-sign <string>        Generate Digiflazz sign",
 			"generate-secret <int>          Generate secret token",
 			"help                           Show this help",
@@ -167,6 +254,12 @@ func main() {
 	cancel()
 	<-mainCtx.Done()
 
+	// Let the Telegram updates being handled finish
+	if telegramPolling != nil {
+		log.Println("Waiting for Telegram updates...")
+		telegramPolling.Wait()
+	}
+
 	// Stop HTTP server
 	if httpServer != nil {
 		log.Println("Stopping HTTP server...")
@@ -3,10 +3,12 @@ package cmd
 import (
 	"errors"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/fidrasofyan/digiflazz-bot/database/repository"
 	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/handler"
 	"github.com/fidrasofyan/digiflazz-bot/internal/middleware"
 	"github.com/fidrasofyan/digiflazz-bot/internal/route"
 	"github.com/fidrasofyan/digiflazz-bot/internal/service"
@@ -35,6 +37,20 @@ func MustStartHTTPServer() *fiber.App {
 				code = fiberErr.Code
 			}
 
+			// The REST API always answers JSON, and hides internal errors
+			if strings.HasPrefix(c.Path(), "/api/") {
+				message := "Internal server error"
+				if fiberErr != nil {
+					message = fiberErr.Message
+				} else {
+					log.Printf("API error: %v", err)
+				}
+				return c.Status(code).JSON(&fiber.Map{
+					"ok":      false,
+					"message": message,
+				})
+			}
+
 			var body types.TelegramUpdate
 			if err := c.BodyParser(&body); err != nil {
 				err = c.Status(code).JSON(&fiber.Map{
@@ -53,38 +69,11 @@ func MustStartHTTPServer() *fiber.App {
 			}
 
 			// At this point, the body is a valid Telegram update
-			log.Printf("Error: %v", err)
-
-			text := "<i>Something went wrong</i>"
-			if errors.Is(err, fiber.ErrRequestTimeout) {
-				text = "<i>Request timeout</i>"
-			}
-
-			// Delete chat
-			_ = repository.TelegramDeleteChat(c.Context(), body.CallbackQuery.From.Id)
-
-			if body.CallbackQuery != nil {
-				// Answer callback query
-				_ = service.TelegramAnswerCallbackQuery(c.Context(), &service.TelegramAnswerCallbackQueryParams{
-					CallbackQueryId: body.CallbackQuery.Id,
-				})
-
-				return c.Status(200).JSON(types.TelegramResponse{
-					Method:    types.TelegramMethodEditMessageText,
-					MessageId: body.CallbackQuery.Message.MessageId,
-					ChatId:    body.CallbackQuery.Message.Chat.Id,
-					ParseMode: types.TelegramParseModeHTML,
-					Text:      text,
-				})
+			resp := route.TelegramErrorResponse(c.Context(), &body, err)
+			if resp == nil {
+				return c.Status(200).SendString("OK")
 			}
-
-			return c.Status(200).JSON(types.TelegramResponse{
-				Method:      types.TelegramMethodSendMessage,
-				ChatId:      body.Message.Chat.Id,
-				ParseMode:   types.TelegramParseModeHTML,
-				Text:        text,
-				ReplyMarkup: types.DefaultReplyMarkup,
-			})
+			return c.Status(200).JSON(resp)
 		},
 	})
 
@@ -95,10 +84,16 @@ func MustStartHTTPServer() *fiber.App {
 	}
 
 	// Routes
+	telegramRateLimiter := middleware.NewTelegramRateLimiter(
+		route.TelegramRouter,
+		config.Cfg.RateLimitPerMinute,
+		config.Cfg.RateLimitExpensivePerMinute,
+	)
 	app.Post(
 		"/telegram",
 		middleware.TelegramAuth(),
-		timeout.NewWithContext(route.Telegram(), 10*time.Second),
+		middleware.TelegramRateLimit(telegramRateLimiter),
+		timeout.NewWithContext(route.Telegram(), handler.PurchaseTimeout()),
 	)
 	app.Post(
 		"/digiflazz",
@@ -106,6 +101,67 @@ func MustStartHTTPServer() *fiber.App {
 		timeout.NewWithContext(route.Digiflazz(), 10*time.Second),
 	)
 
+	// REST API
+	api := app.Group("/api/v1", middleware.APIAuth())
+	api.Get(
+		"/products",
+		middleware.APIScope(repository.APIScopeProductsRead),
+		timeout.NewWithContext(route.APIListProducts(), 10*time.Second),
+	)
+	api.Get(
+		"/products/:code",
+		middleware.APIScope(repository.APIScopeProductsRead),
+		timeout.NewWithContext(route.APIGetProduct(), 10*time.Second),
+	)
+	api.Post(
+		"/transactions",
+		middleware.APIScope(repository.APIScopeTransactionsWrite),
+		// Long enough for the seller, and for failover
+		timeout.NewWithContext(route.APICreateTransaction(), handler.PurchaseTimeout()),
+	)
+	api.Get(
+		"/transactions",
+		middleware.APIScope(repository.APIScopeTransactionsRead),
+		timeout.NewWithContext(route.APIListTransactions(), 10*time.Second),
+	)
+	api.Get(
+		"/transactions/:ref_id",
+		middleware.APIScope(repository.APIScopeTransactionsRead),
+		timeout.NewWithContext(route.APIGetTransaction(), 10*time.Second),
+	)
+	api.Get(
+		"/balance",
+		middleware.APIScope(repository.APIScopeBalanceRead),
+		timeout.NewWithContext(route.APIBalance(), 10*time.Second),
+	)
+	api.Get(
+		"/users",
+		middleware.APIScope(repository.APIScopeUsersRead),
+		timeout.NewWithContext(route.APIListUsers(), 10*time.Second),
+	)
+	api.Get(
+		"/users/:chat_id",
+		middleware.APIScope(repository.APIScopeUsersRead),
+		timeout.NewWithContext(route.APIGetUser(), 10*time.Second),
+	)
+	api.Put(
+		"/users/:chat_id/role",
+		middleware.APIScope(repository.APIScopeUsersWrite),
+		timeout.NewWithContext(route.APISetUserRole(), 10*time.Second),
+	)
+	api.Use(func(c *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+
+	if service.WhatsApp != nil {
+		app.Get("/whatsapp", route.WhatsAppVerify())
+		app.Post(
+			"/whatsapp",
+			middleware.WhatsAppAuth(),
+			route.WhatsApp(telegramRateLimiter),
+		)
+	}
+
 	// Not found
 	app.Use(func(c *fiber.Ctx) error {
 		return c.Status(404).SendString("404 not found")
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/fidrasofyan/digiflazz-bot/database/repository"
+)
+
+// Ledger runs a ledger subcommand.
+//
+//	ledger verify
+//	ledger adjust deposit 1000000 "Deposit BCA"
+func Ledger(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing subcommand: verify or adjust")
+	}
+
+	switch args[0] {
+	case "verify":
+		return verifyLedger(ctx)
+	case "adjust":
+		if len(args) < 3 {
+			return errors.New("usage: ledger adjust <account> <amount> [memo]")
+		}
+		amount, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid amount %q", args[2])
+		}
+		err = repository.AdjustLedger(ctx, args[1], amount, strings.Join(args[3:], " "))
+		if err != nil {
+			return err
+		}
+		log.Printf("Ledger account %s adjusted by %d", args[1], amount)
+		return nil
+	default:
+		return fmt.Errorf("unknown subcommand '%s'", args[0])
+	}
+}
+
+// verifyLedger prints the account balances and every inconsistency found.
+func verifyLedger(ctx context.Context) error {
+	report, err := repository.VerifyLedger(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Accounts:")
+	for _, a := range report.Accounts {
+		fmt.Printf("  %-20s %-10s %15d\n", a.Code, a.Kind, a.Balance)
+	}
+
+	for _, j := range report.UnbalancedJournals {
+		fmt.Printf("Journal %d (%s %s): %d postings summing to %d\n", j.ID, j.Kind, j.RefID, j.Postings, j.Total)
+	}
+	for _, d := range report.WalletDrifts {
+		fmt.Printf("Wallet %d: entries sum to %d, ledger says %d\n", d.ChatID, d.WalletBalance, d.LedgerBalance)
+	}
+
+	if n := report.Inconsistencies(); n > 0 {
+		return fmt.Errorf("%d inconsistencies found", n)
+	}
+	fmt.Printf("Ledger OK: %d journals, %d accounts\n", report.Journals, len(report.Accounts))
+	return nil
+}
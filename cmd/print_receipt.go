@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/receipt"
+)
+
+// PrintReceipt prints the receipt of a successful transaction on an ESC/POS
+// thermal printer, or writes the byte stream to a file.
+//
+//	print-receipt <ref_id> --device /dev/usb/lp0
+//	print-receipt <ref_id> --output struk.bin
+func PrintReceipt(ctx context.Context, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.New("missing ref_id")
+	}
+	refId := args[0]
+
+	flags := flag.NewFlagSet("print-receipt", flag.ContinueOnError)
+	device := flags.String("device", "", "printer device, e.g. /dev/usb/lp0 or /dev/rfcomm0")
+	output := flags.String("output", "", "file to write the ESC/POS bytes to, - for stdout")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if (*device == "") == (*output == "") {
+		return errors.New("either --device or --output is required")
+	}
+
+	r, _, err := receipt.Load(ctx, refId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("transaction %s not found", refId)
+	}
+	if err != nil {
+		return err
+	}
+	data, err := receipt.ESCPOS(r)
+	if err != nil {
+		return err
+	}
+
+	var w io.WriteCloser
+	switch {
+	case *output == "-":
+		w = os.Stdout
+	case *output != "":
+		w, err = os.Create(*output)
+	default:
+		// Printers are character devices, they must not be created or truncated
+		w, err = os.OpenFile(*device, os.O_WRONLY, 0)
+	}
+	if err != nil {
+		return err
+	}
+	if w != os.Stdout {
+		defer w.Close()
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if w != os.Stdout {
+		log.Printf("Receipt %s printed", refId)
+	}
+	return nil
+}
@@ -4,34 +4,55 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/route"
 	"github.com/fidrasofyan/digiflazz-bot/internal/service"
 )
 
 func SetTelegramWebhookAndCommands(ctx context.Context) error {
 	log.Println("Setting webhook and commands...")
 	// Set webhook
-	err := service.TelegramSetWebhook(ctx)
+	err := service.Telegram.SetWebhook(ctx, &service.TelegramSetWebhookParams{
+		Url:                config.Cfg.WebhookURL + "/telegram",
+		SecretToken:        config.Cfg.TelegramWebhookSecretToken,
+		MaxConnections:     50,
+		DropPendingUpdates: true,
+		AllowedUpdates:     []string{"message", "callback_query"},
+	})
 	if err != nil {
 		return fmt.Errorf("setting webhook: %v", err)
 	}
 
-	// Set commands
-	commands := []service.TelegramCommand{
-		{
-			Command:     "/start",
-			Description: "Start the bot",
-		},
-		{
-			Command:     "/help",
-			Description: "Show help message",
-		},
+	// Report delivery problems from before the restart
+	info, err := service.Telegram.GetWebhookInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("getting webhook info: %v", err)
+	}
+	if info.LastErrorMessage != "" {
+		log.Printf(
+			"Webhook last error: %s (%s)",
+			info.LastErrorMessage,
+			time.Unix(info.LastErrorDate, 0).Format("2 Jan 2006 15:04:05 MST"),
+		)
 	}
-	err = service.TelegramSetMyCommands(ctx, commands)
+
+	// Set commands
+	err = SetTelegramCommands(ctx)
 	if err != nil {
-		return fmt.Errorf("setting commands: %v", err)
+		return err
 	}
 
 	log.Println("DONE: setting webhook and commands")
 	return nil
 }
+
+func SetTelegramCommands(ctx context.Context) error {
+	// Generated from the registered commands, scoped per role
+	err := route.TelegramRouter.SyncAllCommands(ctx)
+	if err != nil {
+		return fmt.Errorf("setting commands: %v", err)
+	}
+	return nil
+}
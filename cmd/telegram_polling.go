@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/bot"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/handler"
+	"github.com/fidrasofyan/digiflazz-bot/internal/middleware"
+	"github.com/fidrasofyan/digiflazz-bot/internal/route"
+	"github.com/fidrasofyan/digiflazz-bot/internal/service"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+)
+
+// telegramPollingWorkers is the number of updates handled at the same time in polling mode.
+const telegramPollingWorkers = 16
+
+// TelegramPolling consumes Telegram updates with getUpdates.
+type TelegramPolling struct {
+	dispatcher *bot.Dispatcher
+	offset     *telegramPollingOffset
+	done       chan struct{}
+}
+
+// telegramPollingOffset tracks the updates being handled, so getUpdates only
+// confirms the updates whose handler finished. Telegram sends the others again
+// after a restart, and the router skips those that were handled after all.
+type telegramPollingOffset struct {
+	mu sync.Mutex
+	// next is one past the newest update dispatched
+	next     int64
+	handling map[int64]struct{}
+	// finished is signaled when an update is handled
+	finished chan struct{}
+}
+
+func newTelegramPollingOffset() *telegramPollingOffset {
+	return &telegramPollingOffset{
+		handling: make(map[int64]struct{}),
+		finished: make(chan struct{}, 1),
+	}
+}
+
+// start marks the update as being handled. It returns false if the update was
+// dispatched already, getUpdates returns it until every update before it is handled.
+func (o *telegramPollingOffset) start(updateId int64) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if updateId < o.next {
+		return false
+	}
+	o.next = updateId + 1
+	o.handling[updateId] = struct{}{}
+	return true
+}
+
+// finish marks the update as handled.
+func (o *telegramPollingOffset) finish(updateId int64) {
+	o.mu.Lock()
+	delete(o.handling, updateId)
+	o.mu.Unlock()
+
+	select {
+	case o.finished <- struct{}{}:
+	default:
+	}
+}
+
+// get returns the oldest update being handled, or the next update if none is.
+func (o *telegramPollingOffset) get() int64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	offset := o.next
+	for updateId := range o.handling {
+		offset = min(offset, updateId)
+	}
+	return offset
+}
+
+// StartTelegramPolling deletes the webhook and consumes updates with getUpdates
+// until the context is canceled. Updates go through the same dispatch as the webhook.
+func StartTelegramPolling(ctx context.Context) (*TelegramPolling, error) {
+	log.Println("Deleting webhook...")
+	err := service.Telegram.DeleteWebhook(ctx, &service.TelegramDeleteWebhookParams{
+		DropPendingUpdates: false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("deleting webhook: %v", err)
+	}
+	log.Println("DONE: deleting webhook")
+
+	limiter := middleware.NewTelegramRateLimiter(
+		route.TelegramRouter,
+		config.Cfg.RateLimitPerMinute,
+		config.Cfg.RateLimitExpensivePerMinute,
+	)
+
+	p := &TelegramPolling{
+		dispatcher: bot.NewDispatcher(telegramPollingWorkers),
+		offset:     newTelegramPollingOffset(),
+		done:       make(chan struct{}),
+	}
+
+	go func() {
+		defer close(p.done)
+		log.Println("Polling Telegram updates...")
+		for {
+			updates, err := service.Telegram.GetUpdates(ctx, p.offset.get())
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("TelegramGetUpdates: %v", err)
+				// Back off before retrying
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(5 * time.Second):
+				}
+				continue
+			}
+
+			dispatched := 0
+			for i := range updates {
+				req := &updates[i]
+				if !p.offset.start(req.UpdateId) {
+					continue
+				}
+				dispatched++
+				p.dispatcher.Dispatch(ctx, telegramChatId(req), func(ctx context.Context) {
+					defer p.offset.finish(req.UpdateId)
+					// An update that has started is handled to the end, even
+					// when polling stops
+					handleTelegramUpdate(context.WithoutCancel(ctx), limiter, req)
+				})
+			}
+
+			// Only updates still being handled came back. Wait for one to
+			// finish, or a moment for new ones, instead of asking right away.
+			if len(updates) > 0 && dispatched == 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-p.offset.finished:
+				case <-time.After(time.Second):
+				}
+			}
+		}
+	}()
+
+	return p, nil
+}
+
+// Wait blocks until polling has stopped and the updates being handled are
+// done. Queued updates that haven't started are left for the next start.
+func (p *TelegramPolling) Wait() {
+	<-p.done
+	p.dispatcher.Wait()
+}
+
+// telegramChatId returns the chat an update belongs to.
+func telegramChatId(req *types.TelegramUpdate) int64 {
+	switch {
+	case req.CallbackQuery != nil:
+		return req.CallbackQuery.From.Id
+	case req.Message != nil:
+		return req.Message.Chat.Id
+	}
+	return 0
+}
+
+func handleTelegramUpdate(ctx context.Context, limiter *middleware.TelegramRateLimiter, req *types.TelegramUpdate) {
+	ctx, cancel := context.WithTimeout(ctx, handler.PurchaseTimeout())
+	defer cancel()
+
+	resp, limited := limiter.Check(req)
+	if !limited {
+		var err error
+		resp, err = route.TelegramRouter.Dispatch(ctx, req)
+		if err != nil {
+			resp = route.TelegramErrorResponse(ctx, req, err)
+		}
+	}
+	if resp == nil {
+		return
+	}
+
+	err := service.Telegram.SendResponse(ctx, resp)
+	if err != nil {
+		log.Printf("TelegramSendResponse: %v", err)
+	}
+}
@@ -7,38 +7,32 @@ package database
 
 import (
 	"context"
+	"database/sql"
 )
 
-const createChat = `-- name: CreateChat :one
-INSERT INTO chats (id, command, step, data) 
-VALUES (?, ?, ?, ?) 
-RETURNING id, command, step, data, reply_markup_1, reply_markup_2, reply_markup_3, reply_markup_4
+const claimChat = `-- name: ClaimChat :one
+DELETE FROM chats
+WHERE id = ? AND command = ? AND state = ?
+RETURNING id, command, state, data, stack, updated_at, expires_at
 `
 
-type CreateChatParams struct {
+type ClaimChatParams struct {
 	ID      int64
 	Command string
-	Step    int64
-	Data    []byte
+	State   string
 }
 
-func (q *Queries) CreateChat(ctx context.Context, arg *CreateChatParams) (*Chat, error) {
-	row := q.queryRow(ctx, q.createChatStmt, createChat,
-		arg.ID,
-		arg.Command,
-		arg.Step,
-		arg.Data,
-	)
+func (q *Queries) ClaimChat(ctx context.Context, arg *ClaimChatParams) (*Chat, error) {
+	row := q.queryRow(ctx, q.claimChatStmt, claimChat, arg.ID, arg.Command, arg.State)
 	var i Chat
 	err := row.Scan(
 		&i.ID,
 		&i.Command,
-		&i.Step,
+		&i.State,
 		&i.Data,
-		&i.ReplyMarkup1,
-		&i.ReplyMarkup2,
-		&i.ReplyMarkup3,
-		&i.ReplyMarkup4,
+		&i.Stack,
+		&i.UpdatedAt,
+		&i.ExpiresAt,
 	)
 	return &i, err
 }
@@ -52,8 +46,59 @@ func (q *Queries) DeleteChat(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteChatByCommand = `-- name: DeleteChatByCommand :exec
+DELETE FROM chats WHERE id = ? AND command = ?
+`
+
+type DeleteChatByCommandParams struct {
+	ID      int64
+	Command string
+}
+
+func (q *Queries) DeleteChatByCommand(ctx context.Context, arg *DeleteChatByCommandParams) error {
+	_, err := q.exec(ctx, q.deleteChatByCommandStmt, deleteChatByCommand, arg.ID, arg.Command)
+	return err
+}
+
+const deleteExpiredChats = `-- name: DeleteExpiredChats :many
+DELETE FROM chats
+WHERE expires_at IS NOT NULL AND expires_at < ?
+RETURNING id, command, state, data, stack, updated_at, expires_at
+`
+
+func (q *Queries) DeleteExpiredChats(ctx context.Context, expiresAt sql.NullTime) ([]*Chat, error) {
+	rows, err := q.query(ctx, q.deleteExpiredChatsStmt, deleteExpiredChats, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Chat{}
+	for rows.Next() {
+		var i Chat
+		if err := rows.Scan(
+			&i.ID,
+			&i.Command,
+			&i.State,
+			&i.Data,
+			&i.Stack,
+			&i.UpdatedAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChat = `-- name: GetChat :one
-SELECT id, command, step, data, reply_markup_1, reply_markup_2, reply_markup_3, reply_markup_4 FROM chats WHERE id = ? LIMIT 1
+SELECT id, command, state, data, stack, updated_at, expires_at FROM chats WHERE id = ? LIMIT 1
 `
 
 func (q *Queries) GetChat(ctx context.Context, id int64) (*Chat, error) {
@@ -62,129 +107,57 @@ func (q *Queries) GetChat(ctx context.Context, id int64) (*Chat, error) {
 	err := row.Scan(
 		&i.ID,
 		&i.Command,
-		&i.Step,
+		&i.State,
 		&i.Data,
-		&i.ReplyMarkup1,
-		&i.ReplyMarkup2,
-		&i.ReplyMarkup3,
-		&i.ReplyMarkup4,
+		&i.Stack,
+		&i.UpdatedAt,
+		&i.ExpiresAt,
 	)
 	return &i, err
 }
 
-const isChatExists = `-- name: IsChatExists :one
-SELECT EXISTS(SELECT 1 FROM chats WHERE id = ? LIMIT 1)
+const upsertChat = `-- name: UpsertChat :one
+INSERT INTO chats (id, command, state, data, stack, updated_at, expires_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+  command = excluded.command,
+  state = excluded.state,
+  data = excluded.data,
+  stack = excluded.stack,
+  updated_at = excluded.updated_at,
+  expires_at = excluded.expires_at
+RETURNING id, command, state, data, stack, updated_at, expires_at
 `
 
-func (q *Queries) IsChatExists(ctx context.Context, id int64) (int64, error) {
-	row := q.queryRow(ctx, q.isChatExistsStmt, isChatExists, id)
-	var column_1 int64
-	err := row.Scan(&column_1)
-	return column_1, err
+type UpsertChatParams struct {
+	ID        int64
+	Command   string
+	State     string
+	Data      []byte
+	Stack     []byte
+	UpdatedAt sql.NullTime
+	ExpiresAt sql.NullTime
 }
 
-const updateChat = `-- name: UpdateChat :one
-UPDATE chats
-SET 
-  command = ?, 
-  step = ?, 
-  data = ?
-WHERE id = ?
-RETURNING id, command, step, data, reply_markup_1, reply_markup_2, reply_markup_3, reply_markup_4
-`
-
-type UpdateChatParams struct {
-	Command string
-	Step    int64
-	Data    []byte
-	ID      int64
-}
-
-func (q *Queries) UpdateChat(ctx context.Context, arg *UpdateChatParams) (*Chat, error) {
-	row := q.queryRow(ctx, q.updateChatStmt, updateChat,
+func (q *Queries) UpsertChat(ctx context.Context, arg *UpsertChatParams) (*Chat, error) {
+	row := q.queryRow(ctx, q.upsertChatStmt, upsertChat,
+		arg.ID,
 		arg.Command,
-		arg.Step,
+		arg.State,
 		arg.Data,
-		arg.ID,
+		arg.Stack,
+		arg.UpdatedAt,
+		arg.ExpiresAt,
 	)
 	var i Chat
 	err := row.Scan(
 		&i.ID,
 		&i.Command,
-		&i.Step,
+		&i.State,
 		&i.Data,
-		&i.ReplyMarkup1,
-		&i.ReplyMarkup2,
-		&i.ReplyMarkup3,
-		&i.ReplyMarkup4,
+		&i.Stack,
+		&i.UpdatedAt,
+		&i.ExpiresAt,
 	)
 	return &i, err
 }
-
-const updateReplyMarkup1 = `-- name: UpdateReplyMarkup1 :exec
-UPDATE chats
-SET 
-  reply_markup_1 = ?
-WHERE id = ?
-`
-
-type UpdateReplyMarkup1Params struct {
-	ReplyMarkup1 []byte
-	ID           int64
-}
-
-func (q *Queries) UpdateReplyMarkup1(ctx context.Context, arg *UpdateReplyMarkup1Params) error {
-	_, err := q.exec(ctx, q.updateReplyMarkup1Stmt, updateReplyMarkup1, arg.ReplyMarkup1, arg.ID)
-	return err
-}
-
-const updateReplyMarkup2 = `-- name: UpdateReplyMarkup2 :exec
-UPDATE chats
-SET 
-  reply_markup_2 = ?
-WHERE id = ?
-`
-
-type UpdateReplyMarkup2Params struct {
-	ReplyMarkup2 []byte
-	ID           int64
-}
-
-func (q *Queries) UpdateReplyMarkup2(ctx context.Context, arg *UpdateReplyMarkup2Params) error {
-	_, err := q.exec(ctx, q.updateReplyMarkup2Stmt, updateReplyMarkup2, arg.ReplyMarkup2, arg.ID)
-	return err
-}
-
-const updateReplyMarkup3 = `-- name: UpdateReplyMarkup3 :exec
-UPDATE chats
-SET 
-  reply_markup_3 = ?
-WHERE id = ?
-`
-
-type UpdateReplyMarkup3Params struct {
-	ReplyMarkup3 []byte
-	ID           int64
-}
-
-func (q *Queries) UpdateReplyMarkup3(ctx context.Context, arg *UpdateReplyMarkup3Params) error {
-	_, err := q.exec(ctx, q.updateReplyMarkup3Stmt, updateReplyMarkup3, arg.ReplyMarkup3, arg.ID)
-	return err
-}
-
-const updateReplyMarkup4 = `-- name: UpdateReplyMarkup4 :exec
-UPDATE chats
-SET 
-  reply_markup_4 = ?
-WHERE id = ?
-`
-
-type UpdateReplyMarkup4Params struct {
-	ReplyMarkup4 []byte
-	ID           int64
-}
-
-func (q *Queries) UpdateReplyMarkup4(ctx context.Context, arg *UpdateReplyMarkup4Params) error {
-	_, err := q.exec(ctx, q.updateReplyMarkup4Stmt, updateReplyMarkup4, arg.ReplyMarkup4, arg.ID)
-	return err
-}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"embed"
 	"log"
+	"strings"
 
 	"github.com/fidrasofyan/digiflazz-bot/internal/config"
 	"github.com/pressly/goose/v3"
@@ -21,7 +22,7 @@ func MustLoadDatabase(ctx context.Context) {
 	var err error
 
 	// Open database
-	DBConn, err = sql.Open("sqlite", config.Cfg.DatabaseURL)
+	DBConn, err = sql.Open("sqlite", sqliteDSN(config.Cfg.DatabaseURL))
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
@@ -43,17 +44,28 @@ func MustLoadDatabase(ctx context.Context) {
 		}
 	}
 
+	// Apply migrations (before preparing statements, which need the tables)
+	if err := applyMigrations(DBConn); err != nil {
+		log.Fatalf("Failed to apply migrations: %v", err)
+	}
+
 	// Initialize
 	Sqlc, err = Prepare(ctx, DBConn)
 	if err != nil {
 		log.Fatalf("Failed to initialize SQLC: %v", err)
 	}
 	log.Printf("Database connected (%s)\n", config.Cfg.DatabaseURL)
+}
 
-	// Apply migrations
-	if err := applyMigrations(DBConn); err != nil {
-		log.Fatalf("Failed to apply migrations: %v", err)
+// sqliteDSN adds the options every connection of the pool needs: wait for a
+// lock instead of failing with SQLITE_BUSY, and take the write lock when a
+// transaction begins, so it can't fail when a read is upgraded to a write.
+func sqliteDSN(url string) string {
+	sep := "?"
+	if strings.Contains(url, "?") {
+		sep = "&"
 	}
+	return url + sep + "_pragma=busy_timeout(5000)&_txlock=immediate"
 }
 
 func applyMigrations(db *sql.DB) error {
@@ -24,18 +24,78 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
-	if q.createChatStmt, err = db.PrepareContext(ctx, createChat); err != nil {
-		return nil, fmt.Errorf("error preparing query CreateChat: %w", err)
+	if q.claimChatStmt, err = db.PrepareContext(ctx, claimChat); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimChat: %w", err)
+	}
+	if q.countLedgerJournalsStmt, err = db.PrepareContext(ctx, countLedgerJournals); err != nil {
+		return nil, fmt.Errorf("error preparing query CountLedgerJournals: %w", err)
+	}
+	if q.createApiKeyStmt, err = db.PrepareContext(ctx, createApiKey); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateApiKey: %w", err)
+	}
+	if q.createLedgerJournalStmt, err = db.PrepareContext(ctx, createLedgerJournal); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateLedgerJournal: %w", err)
+	}
+	if q.createLedgerPostingStmt, err = db.PrepareContext(ctx, createLedgerPosting); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateLedgerPosting: %w", err)
+	}
+	if q.createTransactionStmt, err = db.PrepareContext(ctx, createTransaction); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTransaction: %w", err)
+	}
+	if q.createTransactionApprovalStmt, err = db.PrepareContext(ctx, createTransactionApproval); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTransactionApproval: %w", err)
 	}
 	if q.createUserStmt, err = db.PrepareContext(ctx, createUser); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateUser: %w", err)
 	}
+	if q.createWalletEntryStmt, err = db.PrepareContext(ctx, createWalletEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWalletEntry: %w", err)
+	}
+	if q.createWalletTopupStmt, err = db.PrepareContext(ctx, createWalletTopup); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWalletTopup: %w", err)
+	}
+	if q.debitWalletStmt, err = db.PrepareContext(ctx, debitWallet); err != nil {
+		return nil, fmt.Errorf("error preparing query DebitWallet: %w", err)
+	}
+	if q.decideTransactionApprovalStmt, err = db.PrepareContext(ctx, decideTransactionApproval); err != nil {
+		return nil, fmt.Errorf("error preparing query DecideTransactionApproval: %w", err)
+	}
+	if q.decideWalletTopupStmt, err = db.PrepareContext(ctx, decideWalletTopup); err != nil {
+		return nil, fmt.Errorf("error preparing query DecideWalletTopup: %w", err)
+	}
 	if q.deleteAllPrepaidProductsStmt, err = db.PrepareContext(ctx, deleteAllPrepaidProducts); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteAllPrepaidProducts: %w", err)
 	}
 	if q.deleteChatStmt, err = db.PrepareContext(ctx, deleteChat); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteChat: %w", err)
 	}
+	if q.deleteChatByCommandStmt, err = db.PrepareContext(ctx, deleteChatByCommand); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteChatByCommand: %w", err)
+	}
+	if q.deleteExpiredChatsStmt, err = db.PrepareContext(ctx, deleteExpiredChats); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredChats: %w", err)
+	}
+	if q.deleteTelegramUpdateStmt, err = db.PrepareContext(ctx, deleteTelegramUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteTelegramUpdate: %w", err)
+	}
+	if q.deleteTelegramUpdatesBeforeStmt, err = db.PrepareContext(ctx, deleteTelegramUpdatesBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteTelegramUpdatesBefore: %w", err)
+	}
+	if q.deleteUserLimitStmt, err = db.PrepareContext(ctx, deleteUserLimit); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserLimit: %w", err)
+	}
+	if q.deleteUserPinStmt, err = db.PrepareContext(ctx, deleteUserPin); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserPin: %w", err)
+	}
+	if q.deleteUserRoleStmt, err = db.PrepareContext(ctx, deleteUserRole); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserRole: %w", err)
+	}
+	if q.deleteWhatsAppMenuStmt, err = db.PrepareContext(ctx, deleteWhatsAppMenu); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWhatsAppMenu: %w", err)
+	}
+	if q.getActiveApiKeyByHashStmt, err = db.PrepareContext(ctx, getActiveApiKeyByHash); err != nil {
+		return nil, fmt.Errorf("error preparing query GetActiveApiKeyByHash: %w", err)
+	}
 	if q.getBrandsByCategoryStmt, err = db.PrepareContext(ctx, getBrandsByCategory); err != nil {
 		return nil, fmt.Errorf("error preparing query GetBrandsByCategory: %w", err)
 	}
@@ -45,50 +105,182 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getChatStmt, err = db.PrepareContext(ctx, getChat); err != nil {
 		return nil, fmt.Errorf("error preparing query GetChat: %w", err)
 	}
+	if q.getDailyTransactionSummaryStmt, err = db.PrepareContext(ctx, getDailyTransactionSummary); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDailyTransactionSummary: %w", err)
+	}
+	if q.getLatestTransactionBySKUAndCustomerNoStmt, err = db.PrepareContext(ctx, getLatestTransactionBySKUAndCustomerNo); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLatestTransactionBySKUAndCustomerNo: %w", err)
+	}
+	if q.getLedgerAccountStmt, err = db.PrepareContext(ctx, getLedgerAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLedgerAccount: %w", err)
+	}
+	if q.getLedgerJournalStmt, err = db.PrepareContext(ctx, getLedgerJournal); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLedgerJournal: %w", err)
+	}
+	if q.getLedgerJournalAccountAmountStmt, err = db.PrepareContext(ctx, getLedgerJournalAccountAmount); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLedgerJournalAccountAmount: %w", err)
+	}
+	if q.getPLNTokenByRefIDStmt, err = db.PrepareContext(ctx, getPLNTokenByRefID); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPLNTokenByRefID: %w", err)
+	}
 	if q.getPrepaidProductBySKUCodeStmt, err = db.PrepareContext(ctx, getPrepaidProductBySKUCode); err != nil {
 		return nil, fmt.Errorf("error preparing query GetPrepaidProductBySKUCode: %w", err)
 	}
 	if q.getPrepaidProductsStmt, err = db.PrepareContext(ctx, getPrepaidProducts); err != nil {
 		return nil, fmt.Errorf("error preparing query GetPrepaidProducts: %w", err)
 	}
+	if q.getTransactionByRefIDStmt, err = db.PrepareContext(ctx, getTransactionByRefID); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTransactionByRefID: %w", err)
+	}
 	if q.getTypesByCategoryAndBrandStmt, err = db.PrepareContext(ctx, getTypesByCategoryAndBrand); err != nil {
 		return nil, fmt.Errorf("error preparing query GetTypesByCategoryAndBrand: %w", err)
 	}
 	if q.getUserStmt, err = db.PrepareContext(ctx, getUser); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUser: %w", err)
 	}
+	if q.getUserLimitStmt, err = db.PrepareContext(ctx, getUserLimit); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserLimit: %w", err)
+	}
+	if q.getUserPinStmt, err = db.PrepareContext(ctx, getUserPin); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserPin: %w", err)
+	}
+	if q.getUserRoleStmt, err = db.PrepareContext(ctx, getUserRole); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserRole: %w", err)
+	}
+	if q.getWalletBalanceStmt, err = db.PrepareContext(ctx, getWalletBalance); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWalletBalance: %w", err)
+	}
+	if q.getWalletEntryStmt, err = db.PrepareContext(ctx, getWalletEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWalletEntry: %w", err)
+	}
+	if q.getWhatsAppMenuStmt, err = db.PrepareContext(ctx, getWhatsAppMenu); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWhatsAppMenu: %w", err)
+	}
+	if q.incrementUserPinFailedAttemptsStmt, err = db.PrepareContext(ctx, incrementUserPinFailedAttempts); err != nil {
+		return nil, fmt.Errorf("error preparing query IncrementUserPinFailedAttempts: %w", err)
+	}
 	if q.insertPrepaidProductStmt, err = db.PrepareContext(ctx, insertPrepaidProduct); err != nil {
 		return nil, fmt.Errorf("error preparing query InsertPrepaidProduct: %w", err)
 	}
-	if q.isChatExistsStmt, err = db.PrepareContext(ctx, isChatExists); err != nil {
-		return nil, fmt.Errorf("error preparing query IsChatExists: %w", err)
+	if q.insertTelegramUpdateStmt, err = db.PrepareContext(ctx, insertTelegramUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertTelegramUpdate: %w", err)
 	}
 	if q.isUserExistsStmt, err = db.PrepareContext(ctx, isUserExists); err != nil {
 		return nil, fmt.Errorf("error preparing query IsUserExists: %w", err)
 	}
-	if q.updateChatStmt, err = db.PrepareContext(ctx, updateChat); err != nil {
-		return nil, fmt.Errorf("error preparing query UpdateChat: %w", err)
+	if q.listApiKeysStmt, err = db.PrepareContext(ctx, listApiKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query ListApiKeys: %w", err)
+	}
+	if q.listEquivalentPrepaidProductsStmt, err = db.PrepareContext(ctx, listEquivalentPrepaidProducts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListEquivalentPrepaidProducts: %w", err)
+	}
+	if q.listLedgerAccountsStmt, err = db.PrepareContext(ctx, listLedgerAccounts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListLedgerAccounts: %w", err)
+	}
+	if q.listLedgerPostingsStmt, err = db.PrepareContext(ctx, listLedgerPostings); err != nil {
+		return nil, fmt.Errorf("error preparing query ListLedgerPostings: %w", err)
+	}
+	if q.listPendingTransactionsStmt, err = db.PrepareContext(ctx, listPendingTransactions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPendingTransactions: %w", err)
+	}
+	if q.listTransactionsStmt, err = db.PrepareContext(ctx, listTransactions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTransactions: %w", err)
+	}
+	if q.listUnbalancedLedgerJournalsStmt, err = db.PrepareContext(ctx, listUnbalancedLedgerJournals); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUnbalancedLedgerJournals: %w", err)
+	}
+	if q.listUserRolesStmt, err = db.PrepareContext(ctx, listUserRoles); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUserRoles: %w", err)
 	}
-	if q.updateReplyMarkup1Stmt, err = db.PrepareContext(ctx, updateReplyMarkup1); err != nil {
-		return nil, fmt.Errorf("error preparing query UpdateReplyMarkup1: %w", err)
+	if q.listUsersStmt, err = db.PrepareContext(ctx, listUsers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUsers: %w", err)
 	}
-	if q.updateReplyMarkup2Stmt, err = db.PrepareContext(ctx, updateReplyMarkup2); err != nil {
-		return nil, fmt.Errorf("error preparing query UpdateReplyMarkup2: %w", err)
+	if q.listWalletEntriesStmt, err = db.PrepareContext(ctx, listWalletEntries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWalletEntries: %w", err)
 	}
-	if q.updateReplyMarkup3Stmt, err = db.PrepareContext(ctx, updateReplyMarkup3); err != nil {
-		return nil, fmt.Errorf("error preparing query UpdateReplyMarkup3: %w", err)
+	if q.listWalletLedgerDriftsStmt, err = db.PrepareContext(ctx, listWalletLedgerDrifts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWalletLedgerDrifts: %w", err)
 	}
-	if q.updateReplyMarkup4Stmt, err = db.PrepareContext(ctx, updateReplyMarkup4); err != nil {
-		return nil, fmt.Errorf("error preparing query UpdateReplyMarkup4: %w", err)
+	if q.lockUserPinStmt, err = db.PrepareContext(ctx, lockUserPin); err != nil {
+		return nil, fmt.Errorf("error preparing query LockUserPin: %w", err)
+	}
+	if q.refundWalletPurchaseStmt, err = db.PrepareContext(ctx, refundWalletPurchase); err != nil {
+		return nil, fmt.Errorf("error preparing query RefundWalletPurchase: %w", err)
+	}
+	if q.resetUserPinFailedAttemptsStmt, err = db.PrepareContext(ctx, resetUserPinFailedAttempts); err != nil {
+		return nil, fmt.Errorf("error preparing query ResetUserPinFailedAttempts: %w", err)
+	}
+	if q.revokeApiKeyStmt, err = db.PrepareContext(ctx, revokeApiKey); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeApiKey: %w", err)
+	}
+	if q.searchPrepaidProductsStmt, err = db.PrepareContext(ctx, searchPrepaidProducts); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchPrepaidProducts: %w", err)
+	}
+	if q.touchApiKeyStmt, err = db.PrepareContext(ctx, touchApiKey); err != nil {
+		return nil, fmt.Errorf("error preparing query TouchApiKey: %w", err)
+	}
+	if q.updateTransactionByRefIDStmt, err = db.PrepareContext(ctx, updateTransactionByRefID); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateTransactionByRefID: %w", err)
+	}
+	if q.upsertChatStmt, err = db.PrepareContext(ctx, upsertChat); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertChat: %w", err)
+	}
+	if q.upsertLedgerAccountStmt, err = db.PrepareContext(ctx, upsertLedgerAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertLedgerAccount: %w", err)
+	}
+	if q.upsertPLNTokenStmt, err = db.PrepareContext(ctx, upsertPLNToken); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertPLNToken: %w", err)
+	}
+	if q.upsertUserLimitStmt, err = db.PrepareContext(ctx, upsertUserLimit); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertUserLimit: %w", err)
+	}
+	if q.upsertUserPinStmt, err = db.PrepareContext(ctx, upsertUserPin); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertUserPin: %w", err)
+	}
+	if q.upsertUserRoleStmt, err = db.PrepareContext(ctx, upsertUserRole); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertUserRole: %w", err)
+	}
+	if q.upsertWhatsAppMenuStmt, err = db.PrepareContext(ctx, upsertWhatsAppMenu); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertWhatsAppMenu: %w", err)
 	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
-	if q.createChatStmt != nil {
-		if cerr := q.createChatStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing createChatStmt: %w", cerr)
+	if q.claimChatStmt != nil {
+		if cerr := q.claimChatStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimChatStmt: %w", cerr)
+		}
+	}
+	if q.countLedgerJournalsStmt != nil {
+		if cerr := q.countLedgerJournalsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countLedgerJournalsStmt: %w", cerr)
+		}
+	}
+	if q.createApiKeyStmt != nil {
+		if cerr := q.createApiKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createApiKeyStmt: %w", cerr)
+		}
+	}
+	if q.createLedgerJournalStmt != nil {
+		if cerr := q.createLedgerJournalStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createLedgerJournalStmt: %w", cerr)
+		}
+	}
+	if q.createLedgerPostingStmt != nil {
+		if cerr := q.createLedgerPostingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createLedgerPostingStmt: %w", cerr)
+		}
+	}
+	if q.createTransactionStmt != nil {
+		if cerr := q.createTransactionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTransactionStmt: %w", cerr)
+		}
+	}
+	if q.createTransactionApprovalStmt != nil {
+		if cerr := q.createTransactionApprovalStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTransactionApprovalStmt: %w", cerr)
 		}
 	}
 	if q.createUserStmt != nil {
@@ -96,6 +288,31 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createUserStmt: %w", cerr)
 		}
 	}
+	if q.createWalletEntryStmt != nil {
+		if cerr := q.createWalletEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWalletEntryStmt: %w", cerr)
+		}
+	}
+	if q.createWalletTopupStmt != nil {
+		if cerr := q.createWalletTopupStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWalletTopupStmt: %w", cerr)
+		}
+	}
+	if q.debitWalletStmt != nil {
+		if cerr := q.debitWalletStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing debitWalletStmt: %w", cerr)
+		}
+	}
+	if q.decideTransactionApprovalStmt != nil {
+		if cerr := q.decideTransactionApprovalStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing decideTransactionApprovalStmt: %w", cerr)
+		}
+	}
+	if q.decideWalletTopupStmt != nil {
+		if cerr := q.decideWalletTopupStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing decideWalletTopupStmt: %w", cerr)
+		}
+	}
 	if q.deleteAllPrepaidProductsStmt != nil {
 		if cerr := q.deleteAllPrepaidProductsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteAllPrepaidProductsStmt: %w", cerr)
@@ -106,6 +323,51 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteChatStmt: %w", cerr)
 		}
 	}
+	if q.deleteChatByCommandStmt != nil {
+		if cerr := q.deleteChatByCommandStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteChatByCommandStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredChatsStmt != nil {
+		if cerr := q.deleteExpiredChatsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredChatsStmt: %w", cerr)
+		}
+	}
+	if q.deleteTelegramUpdateStmt != nil {
+		if cerr := q.deleteTelegramUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteTelegramUpdateStmt: %w", cerr)
+		}
+	}
+	if q.deleteTelegramUpdatesBeforeStmt != nil {
+		if cerr := q.deleteTelegramUpdatesBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteTelegramUpdatesBeforeStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserLimitStmt != nil {
+		if cerr := q.deleteUserLimitStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserLimitStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserPinStmt != nil {
+		if cerr := q.deleteUserPinStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserPinStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserRoleStmt != nil {
+		if cerr := q.deleteUserRoleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserRoleStmt: %w", cerr)
+		}
+	}
+	if q.deleteWhatsAppMenuStmt != nil {
+		if cerr := q.deleteWhatsAppMenuStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWhatsAppMenuStmt: %w", cerr)
+		}
+	}
+	if q.getActiveApiKeyByHashStmt != nil {
+		if cerr := q.getActiveApiKeyByHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getActiveApiKeyByHashStmt: %w", cerr)
+		}
+	}
 	if q.getBrandsByCategoryStmt != nil {
 		if cerr := q.getBrandsByCategoryStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getBrandsByCategoryStmt: %w", cerr)
@@ -121,6 +383,36 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getChatStmt: %w", cerr)
 		}
 	}
+	if q.getDailyTransactionSummaryStmt != nil {
+		if cerr := q.getDailyTransactionSummaryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDailyTransactionSummaryStmt: %w", cerr)
+		}
+	}
+	if q.getLatestTransactionBySKUAndCustomerNoStmt != nil {
+		if cerr := q.getLatestTransactionBySKUAndCustomerNoStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLatestTransactionBySKUAndCustomerNoStmt: %w", cerr)
+		}
+	}
+	if q.getLedgerAccountStmt != nil {
+		if cerr := q.getLedgerAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLedgerAccountStmt: %w", cerr)
+		}
+	}
+	if q.getLedgerJournalStmt != nil {
+		if cerr := q.getLedgerJournalStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLedgerJournalStmt: %w", cerr)
+		}
+	}
+	if q.getLedgerJournalAccountAmountStmt != nil {
+		if cerr := q.getLedgerJournalAccountAmountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLedgerJournalAccountAmountStmt: %w", cerr)
+		}
+	}
+	if q.getPLNTokenByRefIDStmt != nil {
+		if cerr := q.getPLNTokenByRefIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPLNTokenByRefIDStmt: %w", cerr)
+		}
+	}
 	if q.getPrepaidProductBySKUCodeStmt != nil {
 		if cerr := q.getPrepaidProductBySKUCodeStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getPrepaidProductBySKUCodeStmt: %w", cerr)
@@ -131,6 +423,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getPrepaidProductsStmt: %w", cerr)
 		}
 	}
+	if q.getTransactionByRefIDStmt != nil {
+		if cerr := q.getTransactionByRefIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTransactionByRefIDStmt: %w", cerr)
+		}
+	}
 	if q.getTypesByCategoryAndBrandStmt != nil {
 		if cerr := q.getTypesByCategoryAndBrandStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getTypesByCategoryAndBrandStmt: %w", cerr)
@@ -141,14 +438,49 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getUserStmt: %w", cerr)
 		}
 	}
+	if q.getUserLimitStmt != nil {
+		if cerr := q.getUserLimitStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserLimitStmt: %w", cerr)
+		}
+	}
+	if q.getUserPinStmt != nil {
+		if cerr := q.getUserPinStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserPinStmt: %w", cerr)
+		}
+	}
+	if q.getUserRoleStmt != nil {
+		if cerr := q.getUserRoleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserRoleStmt: %w", cerr)
+		}
+	}
+	if q.getWalletBalanceStmt != nil {
+		if cerr := q.getWalletBalanceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWalletBalanceStmt: %w", cerr)
+		}
+	}
+	if q.getWalletEntryStmt != nil {
+		if cerr := q.getWalletEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWalletEntryStmt: %w", cerr)
+		}
+	}
+	if q.getWhatsAppMenuStmt != nil {
+		if cerr := q.getWhatsAppMenuStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWhatsAppMenuStmt: %w", cerr)
+		}
+	}
+	if q.incrementUserPinFailedAttemptsStmt != nil {
+		if cerr := q.incrementUserPinFailedAttemptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing incrementUserPinFailedAttemptsStmt: %w", cerr)
+		}
+	}
 	if q.insertPrepaidProductStmt != nil {
 		if cerr := q.insertPrepaidProductStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing insertPrepaidProductStmt: %w", cerr)
 		}
 	}
-	if q.isChatExistsStmt != nil {
-		if cerr := q.isChatExistsStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing isChatExistsStmt: %w", cerr)
+	if q.insertTelegramUpdateStmt != nil {
+		if cerr := q.insertTelegramUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertTelegramUpdateStmt: %w", cerr)
 		}
 	}
 	if q.isUserExistsStmt != nil {
@@ -156,29 +488,129 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing isUserExistsStmt: %w", cerr)
 		}
 	}
-	if q.updateChatStmt != nil {
-		if cerr := q.updateChatStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing updateChatStmt: %w", cerr)
+	if q.listApiKeysStmt != nil {
+		if cerr := q.listApiKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listApiKeysStmt: %w", cerr)
+		}
+	}
+	if q.listEquivalentPrepaidProductsStmt != nil {
+		if cerr := q.listEquivalentPrepaidProductsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listEquivalentPrepaidProductsStmt: %w", cerr)
+		}
+	}
+	if q.listLedgerAccountsStmt != nil {
+		if cerr := q.listLedgerAccountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listLedgerAccountsStmt: %w", cerr)
+		}
+	}
+	if q.listLedgerPostingsStmt != nil {
+		if cerr := q.listLedgerPostingsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listLedgerPostingsStmt: %w", cerr)
+		}
+	}
+	if q.listPendingTransactionsStmt != nil {
+		if cerr := q.listPendingTransactionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPendingTransactionsStmt: %w", cerr)
+		}
+	}
+	if q.listTransactionsStmt != nil {
+		if cerr := q.listTransactionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTransactionsStmt: %w", cerr)
+		}
+	}
+	if q.listUnbalancedLedgerJournalsStmt != nil {
+		if cerr := q.listUnbalancedLedgerJournalsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUnbalancedLedgerJournalsStmt: %w", cerr)
+		}
+	}
+	if q.listUserRolesStmt != nil {
+		if cerr := q.listUserRolesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUserRolesStmt: %w", cerr)
+		}
+	}
+	if q.listUsersStmt != nil {
+		if cerr := q.listUsersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUsersStmt: %w", cerr)
+		}
+	}
+	if q.listWalletEntriesStmt != nil {
+		if cerr := q.listWalletEntriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWalletEntriesStmt: %w", cerr)
+		}
+	}
+	if q.listWalletLedgerDriftsStmt != nil {
+		if cerr := q.listWalletLedgerDriftsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWalletLedgerDriftsStmt: %w", cerr)
+		}
+	}
+	if q.lockUserPinStmt != nil {
+		if cerr := q.lockUserPinStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing lockUserPinStmt: %w", cerr)
+		}
+	}
+	if q.refundWalletPurchaseStmt != nil {
+		if cerr := q.refundWalletPurchaseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing refundWalletPurchaseStmt: %w", cerr)
+		}
+	}
+	if q.resetUserPinFailedAttemptsStmt != nil {
+		if cerr := q.resetUserPinFailedAttemptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing resetUserPinFailedAttemptsStmt: %w", cerr)
+		}
+	}
+	if q.revokeApiKeyStmt != nil {
+		if cerr := q.revokeApiKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeApiKeyStmt: %w", cerr)
+		}
+	}
+	if q.searchPrepaidProductsStmt != nil {
+		if cerr := q.searchPrepaidProductsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchPrepaidProductsStmt: %w", cerr)
+		}
+	}
+	if q.touchApiKeyStmt != nil {
+		if cerr := q.touchApiKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing touchApiKeyStmt: %w", cerr)
+		}
+	}
+	if q.updateTransactionByRefIDStmt != nil {
+		if cerr := q.updateTransactionByRefIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateTransactionByRefIDStmt: %w", cerr)
+		}
+	}
+	if q.upsertChatStmt != nil {
+		if cerr := q.upsertChatStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertChatStmt: %w", cerr)
+		}
+	}
+	if q.upsertLedgerAccountStmt != nil {
+		if cerr := q.upsertLedgerAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertLedgerAccountStmt: %w", cerr)
+		}
+	}
+	if q.upsertPLNTokenStmt != nil {
+		if cerr := q.upsertPLNTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertPLNTokenStmt: %w", cerr)
 		}
 	}
-	if q.updateReplyMarkup1Stmt != nil {
-		if cerr := q.updateReplyMarkup1Stmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing updateReplyMarkup1Stmt: %w", cerr)
+	if q.upsertUserLimitStmt != nil {
+		if cerr := q.upsertUserLimitStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertUserLimitStmt: %w", cerr)
 		}
 	}
-	if q.updateReplyMarkup2Stmt != nil {
-		if cerr := q.updateReplyMarkup2Stmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing updateReplyMarkup2Stmt: %w", cerr)
+	if q.upsertUserPinStmt != nil {
+		if cerr := q.upsertUserPinStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertUserPinStmt: %w", cerr)
 		}
 	}
-	if q.updateReplyMarkup3Stmt != nil {
-		if cerr := q.updateReplyMarkup3Stmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing updateReplyMarkup3Stmt: %w", cerr)
+	if q.upsertUserRoleStmt != nil {
+		if cerr := q.upsertUserRoleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertUserRoleStmt: %w", cerr)
 		}
 	}
-	if q.updateReplyMarkup4Stmt != nil {
-		if cerr := q.updateReplyMarkup4Stmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing updateReplyMarkup4Stmt: %w", cerr)
+	if q.upsertWhatsAppMenuStmt != nil {
+		if cerr := q.upsertWhatsAppMenuStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertWhatsAppMenuStmt: %w", cerr)
 		}
 	}
 	return err
@@ -218,51 +650,159 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                             DBTX
-	tx                             *sql.Tx
-	createChatStmt                 *sql.Stmt
-	createUserStmt                 *sql.Stmt
-	deleteAllPrepaidProductsStmt   *sql.Stmt
-	deleteChatStmt                 *sql.Stmt
-	getBrandsByCategoryStmt        *sql.Stmt
-	getCategoriesStmt              *sql.Stmt
-	getChatStmt                    *sql.Stmt
-	getPrepaidProductBySKUCodeStmt *sql.Stmt
-	getPrepaidProductsStmt         *sql.Stmt
-	getTypesByCategoryAndBrandStmt *sql.Stmt
-	getUserStmt                    *sql.Stmt
-	insertPrepaidProductStmt       *sql.Stmt
-	isChatExistsStmt               *sql.Stmt
-	isUserExistsStmt               *sql.Stmt
-	updateChatStmt                 *sql.Stmt
-	updateReplyMarkup1Stmt         *sql.Stmt
-	updateReplyMarkup2Stmt         *sql.Stmt
-	updateReplyMarkup3Stmt         *sql.Stmt
-	updateReplyMarkup4Stmt         *sql.Stmt
+	db                                         DBTX
+	tx                                         *sql.Tx
+	claimChatStmt                              *sql.Stmt
+	countLedgerJournalsStmt                    *sql.Stmt
+	createApiKeyStmt                           *sql.Stmt
+	createLedgerJournalStmt                    *sql.Stmt
+	createLedgerPostingStmt                    *sql.Stmt
+	createTransactionStmt                      *sql.Stmt
+	createTransactionApprovalStmt              *sql.Stmt
+	createUserStmt                             *sql.Stmt
+	createWalletEntryStmt                      *sql.Stmt
+	createWalletTopupStmt                      *sql.Stmt
+	debitWalletStmt                            *sql.Stmt
+	decideTransactionApprovalStmt              *sql.Stmt
+	decideWalletTopupStmt                      *sql.Stmt
+	deleteAllPrepaidProductsStmt               *sql.Stmt
+	deleteChatStmt                             *sql.Stmt
+	deleteChatByCommandStmt                    *sql.Stmt
+	deleteExpiredChatsStmt                     *sql.Stmt
+	deleteTelegramUpdateStmt                   *sql.Stmt
+	deleteTelegramUpdatesBeforeStmt            *sql.Stmt
+	deleteUserLimitStmt                        *sql.Stmt
+	deleteUserPinStmt                          *sql.Stmt
+	deleteUserRoleStmt                         *sql.Stmt
+	deleteWhatsAppMenuStmt                     *sql.Stmt
+	getActiveApiKeyByHashStmt                  *sql.Stmt
+	getBrandsByCategoryStmt                    *sql.Stmt
+	getCategoriesStmt                          *sql.Stmt
+	getChatStmt                                *sql.Stmt
+	getDailyTransactionSummaryStmt             *sql.Stmt
+	getLatestTransactionBySKUAndCustomerNoStmt *sql.Stmt
+	getLedgerAccountStmt                       *sql.Stmt
+	getLedgerJournalStmt                       *sql.Stmt
+	getLedgerJournalAccountAmountStmt          *sql.Stmt
+	getPLNTokenByRefIDStmt                     *sql.Stmt
+	getPrepaidProductBySKUCodeStmt             *sql.Stmt
+	getPrepaidProductsStmt                     *sql.Stmt
+	getTransactionByRefIDStmt                  *sql.Stmt
+	getTypesByCategoryAndBrandStmt             *sql.Stmt
+	getUserStmt                                *sql.Stmt
+	getUserLimitStmt                           *sql.Stmt
+	getUserPinStmt                             *sql.Stmt
+	getUserRoleStmt                            *sql.Stmt
+	getWalletBalanceStmt                       *sql.Stmt
+	getWalletEntryStmt                         *sql.Stmt
+	getWhatsAppMenuStmt                        *sql.Stmt
+	incrementUserPinFailedAttemptsStmt         *sql.Stmt
+	insertPrepaidProductStmt                   *sql.Stmt
+	insertTelegramUpdateStmt                   *sql.Stmt
+	isUserExistsStmt                           *sql.Stmt
+	listApiKeysStmt                            *sql.Stmt
+	listEquivalentPrepaidProductsStmt          *sql.Stmt
+	listLedgerAccountsStmt                     *sql.Stmt
+	listLedgerPostingsStmt                     *sql.Stmt
+	listPendingTransactionsStmt                *sql.Stmt
+	listTransactionsStmt                       *sql.Stmt
+	listUnbalancedLedgerJournalsStmt           *sql.Stmt
+	listUserRolesStmt                          *sql.Stmt
+	listUsersStmt                              *sql.Stmt
+	listWalletEntriesStmt                      *sql.Stmt
+	listWalletLedgerDriftsStmt                 *sql.Stmt
+	lockUserPinStmt                            *sql.Stmt
+	refundWalletPurchaseStmt                   *sql.Stmt
+	resetUserPinFailedAttemptsStmt             *sql.Stmt
+	revokeApiKeyStmt                           *sql.Stmt
+	searchPrepaidProductsStmt                  *sql.Stmt
+	touchApiKeyStmt                            *sql.Stmt
+	updateTransactionByRefIDStmt               *sql.Stmt
+	upsertChatStmt                             *sql.Stmt
+	upsertLedgerAccountStmt                    *sql.Stmt
+	upsertPLNTokenStmt                         *sql.Stmt
+	upsertUserLimitStmt                        *sql.Stmt
+	upsertUserPinStmt                          *sql.Stmt
+	upsertUserRoleStmt                         *sql.Stmt
+	upsertWhatsAppMenuStmt                     *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                             tx,
-		tx:                             tx,
-		createChatStmt:                 q.createChatStmt,
-		createUserStmt:                 q.createUserStmt,
-		deleteAllPrepaidProductsStmt:   q.deleteAllPrepaidProductsStmt,
-		deleteChatStmt:                 q.deleteChatStmt,
-		getBrandsByCategoryStmt:        q.getBrandsByCategoryStmt,
-		getCategoriesStmt:              q.getCategoriesStmt,
-		getChatStmt:                    q.getChatStmt,
-		getPrepaidProductBySKUCodeStmt: q.getPrepaidProductBySKUCodeStmt,
-		getPrepaidProductsStmt:         q.getPrepaidProductsStmt,
-		getTypesByCategoryAndBrandStmt: q.getTypesByCategoryAndBrandStmt,
-		getUserStmt:                    q.getUserStmt,
-		insertPrepaidProductStmt:       q.insertPrepaidProductStmt,
-		isChatExistsStmt:               q.isChatExistsStmt,
-		isUserExistsStmt:               q.isUserExistsStmt,
-		updateChatStmt:                 q.updateChatStmt,
-		updateReplyMarkup1Stmt:         q.updateReplyMarkup1Stmt,
-		updateReplyMarkup2Stmt:         q.updateReplyMarkup2Stmt,
-		updateReplyMarkup3Stmt:         q.updateReplyMarkup3Stmt,
-		updateReplyMarkup4Stmt:         q.updateReplyMarkup4Stmt,
+		db:                                         tx,
+		tx:                                         tx,
+		claimChatStmt:                              q.claimChatStmt,
+		countLedgerJournalsStmt:                    q.countLedgerJournalsStmt,
+		createApiKeyStmt:                           q.createApiKeyStmt,
+		createLedgerJournalStmt:                    q.createLedgerJournalStmt,
+		createLedgerPostingStmt:                    q.createLedgerPostingStmt,
+		createTransactionStmt:                      q.createTransactionStmt,
+		createTransactionApprovalStmt:              q.createTransactionApprovalStmt,
+		createUserStmt:                             q.createUserStmt,
+		createWalletEntryStmt:                      q.createWalletEntryStmt,
+		createWalletTopupStmt:                      q.createWalletTopupStmt,
+		debitWalletStmt:                            q.debitWalletStmt,
+		decideTransactionApprovalStmt:              q.decideTransactionApprovalStmt,
+		decideWalletTopupStmt:                      q.decideWalletTopupStmt,
+		deleteAllPrepaidProductsStmt:               q.deleteAllPrepaidProductsStmt,
+		deleteChatStmt:                             q.deleteChatStmt,
+		deleteChatByCommandStmt:                    q.deleteChatByCommandStmt,
+		deleteExpiredChatsStmt:                     q.deleteExpiredChatsStmt,
+		deleteTelegramUpdateStmt:                   q.deleteTelegramUpdateStmt,
+		deleteTelegramUpdatesBeforeStmt:            q.deleteTelegramUpdatesBeforeStmt,
+		deleteUserLimitStmt:                        q.deleteUserLimitStmt,
+		deleteUserPinStmt:                          q.deleteUserPinStmt,
+		deleteUserRoleStmt:                         q.deleteUserRoleStmt,
+		deleteWhatsAppMenuStmt:                     q.deleteWhatsAppMenuStmt,
+		getActiveApiKeyByHashStmt:                  q.getActiveApiKeyByHashStmt,
+		getBrandsByCategoryStmt:                    q.getBrandsByCategoryStmt,
+		getCategoriesStmt:                          q.getCategoriesStmt,
+		getChatStmt:                                q.getChatStmt,
+		getDailyTransactionSummaryStmt:             q.getDailyTransactionSummaryStmt,
+		getLatestTransactionBySKUAndCustomerNoStmt: q.getLatestTransactionBySKUAndCustomerNoStmt,
+		getLedgerAccountStmt:                       q.getLedgerAccountStmt,
+		getLedgerJournalStmt:                       q.getLedgerJournalStmt,
+		getLedgerJournalAccountAmountStmt:          q.getLedgerJournalAccountAmountStmt,
+		getPLNTokenByRefIDStmt:                     q.getPLNTokenByRefIDStmt,
+		getPrepaidProductBySKUCodeStmt:             q.getPrepaidProductBySKUCodeStmt,
+		getPrepaidProductsStmt:                     q.getPrepaidProductsStmt,
+		getTransactionByRefIDStmt:                  q.getTransactionByRefIDStmt,
+		getTypesByCategoryAndBrandStmt:             q.getTypesByCategoryAndBrandStmt,
+		getUserStmt:                                q.getUserStmt,
+		getUserLimitStmt:                           q.getUserLimitStmt,
+		getUserPinStmt:                             q.getUserPinStmt,
+		getUserRoleStmt:                            q.getUserRoleStmt,
+		getWalletBalanceStmt:                       q.getWalletBalanceStmt,
+		getWalletEntryStmt:                         q.getWalletEntryStmt,
+		getWhatsAppMenuStmt:                        q.getWhatsAppMenuStmt,
+		incrementUserPinFailedAttemptsStmt:         q.incrementUserPinFailedAttemptsStmt,
+		insertPrepaidProductStmt:                   q.insertPrepaidProductStmt,
+		insertTelegramUpdateStmt:                   q.insertTelegramUpdateStmt,
+		isUserExistsStmt:                           q.isUserExistsStmt,
+		listApiKeysStmt:                            q.listApiKeysStmt,
+		listEquivalentPrepaidProductsStmt:          q.listEquivalentPrepaidProductsStmt,
+		listLedgerAccountsStmt:                     q.listLedgerAccountsStmt,
+		listLedgerPostingsStmt:                     q.listLedgerPostingsStmt,
+		listPendingTransactionsStmt:                q.listPendingTransactionsStmt,
+		listTransactionsStmt:                       q.listTransactionsStmt,
+		listUnbalancedLedgerJournalsStmt:           q.listUnbalancedLedgerJournalsStmt,
+		listUserRolesStmt:                          q.listUserRolesStmt,
+		listUsersStmt:                              q.listUsersStmt,
+		listWalletEntriesStmt:                      q.listWalletEntriesStmt,
+		listWalletLedgerDriftsStmt:                 q.listWalletLedgerDriftsStmt,
+		lockUserPinStmt:                            q.lockUserPinStmt,
+		refundWalletPurchaseStmt:                   q.refundWalletPurchaseStmt,
+		resetUserPinFailedAttemptsStmt:             q.resetUserPinFailedAttemptsStmt,
+		revokeApiKeyStmt:                           q.revokeApiKeyStmt,
+		searchPrepaidProductsStmt:                  q.searchPrepaidProductsStmt,
+		touchApiKeyStmt:                            q.touchApiKeyStmt,
+		updateTransactionByRefIDStmt:               q.updateTransactionByRefIDStmt,
+		upsertChatStmt:                             q.upsertChatStmt,
+		upsertLedgerAccountStmt:                    q.upsertLedgerAccountStmt,
+		upsertPLNTokenStmt:                         q.upsertPLNTokenStmt,
+		upsertUserLimitStmt:                        q.upsertUserLimitStmt,
+		upsertUserPinStmt:                          q.upsertUserPinStmt,
+		upsertUserRoleStmt:                         q.upsertUserRoleStmt,
+		upsertWhatsAppMenuStmt:                     q.upsertWhatsAppMenuStmt,
 	}
 }
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- transactions
+CREATE TABLE transactions (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  ref_id text NOT NULL,
+  chat_id integer NOT NULL,
+  buyer_sku_code text NOT NULL,
+  customer_no text NOT NULL,
+  price integer NOT NULL,
+  status text NOT NULL,
+  rc text,
+  sn text,
+  message text,
+  created_at datetime NOT NULL,
+  updated_at datetime NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_transactions_ref_id ON transactions(ref_id);
+CREATE INDEX idx_transactions_chat_id_created_at ON transactions(chat_id, created_at);
+
+-- user_limits
+CREATE TABLE user_limits (
+  id integer PRIMARY KEY,
+  max_trx_price integer NOT NULL,
+  max_daily_total integer NOT NULL,
+  max_daily_count integer NOT NULL,
+  updated_at datetime NOT NULL
+);
+
+-- transaction_approvals
+CREATE TABLE transaction_approvals (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  chat_id integer NOT NULL,
+  buyer_sku_code text NOT NULL,
+  customer_no text NOT NULL,
+  price integer NOT NULL,
+  reason text NOT NULL,
+  status text NOT NULL,
+  decided_by integer,
+  created_at datetime NOT NULL,
+  decided_at datetime
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE transaction_approvals;
+DROP TABLE user_limits;
+DROP TABLE transactions;
+-- +goose StatementEnd
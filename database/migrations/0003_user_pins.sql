@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- user_pins
+CREATE TABLE user_pins (
+  id integer PRIMARY KEY,
+  pin_hash text NOT NULL,
+  failed_attempts integer NOT NULL DEFAULT 0,
+  locked_until datetime,
+  updated_at datetime NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_pins;
+-- +goose StatementEnd
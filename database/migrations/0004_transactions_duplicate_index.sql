@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX idx_transactions_buyer_sku_code_customer_no ON transactions(buyer_sku_code COLLATE NOCASE, customer_no);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_transactions_buyer_sku_code_customer_no;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- telegram_updates
+CREATE TABLE telegram_updates (
+  id integer PRIMARY KEY,
+  created_at datetime NOT NULL
+);
+
+CREATE INDEX idx_telegram_updates_created_at ON telegram_updates(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE telegram_updates;
+-- +goose StatementEnd
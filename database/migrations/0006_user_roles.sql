@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- user_roles overrides the roles from TELEGRAM_ALLOWED_IDS and TELEGRAM_ADMIN_IDS
+CREATE TABLE user_roles (
+  id integer PRIMARY KEY,
+  role text NOT NULL,
+  updated_by integer,
+  updated_at datetime NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_roles;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- chats holds one conversation per chat. Conversations in progress are
+-- short-lived, so they are dropped instead of converted.
+DROP TABLE chats;
+CREATE TABLE chats (
+  id integer PRIMARY KEY,
+  command text NOT NULL,
+  state text NOT NULL,
+  data json,
+  stack json,
+  updated_at datetime NOT NULL,
+  expires_at datetime
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chats;
+CREATE TABLE chats (
+  id integer PRIMARY KEY,
+  command text NOT NULL,
+  step integer NOT NULL,
+  data json,
+  reply_markup_1 json,
+  reply_markup_2 json,
+  reply_markup_3 json,
+  reply_markup_4 json
+);
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- pln_tokens holds the fields packed into the SN of PLN prepaid transactions
+CREATE TABLE pln_tokens (
+  ref_id text PRIMARY KEY,
+  token text NOT NULL,
+  customer_name text,
+  tariff text,
+  power text,
+  kwh text,
+  created_at datetime NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE pln_tokens;
+-- +goose StatementEnd
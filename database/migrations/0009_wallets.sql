@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- wallet_entries is the ledger of customer wallets. Credits are positive,
+-- debits negative, and the balance is their sum.
+CREATE TABLE wallet_entries (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  chat_id integer NOT NULL,
+  amount integer NOT NULL,
+  kind text NOT NULL,
+  ref_id text NOT NULL,
+  created_at datetime NOT NULL
+);
+
+CREATE INDEX idx_wallet_entries_chat_id ON wallet_entries(chat_id, id);
+-- A top-up is credited, and a purchase debited or refunded, only once
+CREATE UNIQUE INDEX idx_wallet_entries_kind_ref_id ON wallet_entries(kind, ref_id);
+
+-- wallet_topups are top-up requests waiting for an admin
+CREATE TABLE wallet_topups (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  chat_id integer NOT NULL,
+  amount integer NOT NULL,
+  status text NOT NULL,
+  decided_by integer,
+  created_at datetime NOT NULL,
+  decided_at datetime
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE wallet_topups;
+DROP TABLE wallet_entries;
+-- +goose StatementEnd
@@ -0,0 +1,89 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ledger_accounts are the accounts of the double-entry ledger. Their balance
+-- is always the sum of their postings.
+CREATE TABLE ledger_accounts (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  code text NOT NULL UNIQUE,
+  kind text NOT NULL,
+  chat_id integer,
+  created_at datetime NOT NULL
+);
+
+-- ledger_journals group the postings of one event
+CREATE TABLE ledger_journals (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  kind text NOT NULL,
+  ref_id text NOT NULL,
+  memo text,
+  created_at datetime NOT NULL
+);
+
+-- An event is only journaled once
+CREATE UNIQUE INDEX idx_ledger_journals_kind_ref_id ON ledger_journals(kind, ref_id);
+
+-- ledger_postings move an amount in or out of an account. Debits are positive,
+-- credits negative, and the postings of a journal sum to zero.
+CREATE TABLE ledger_postings (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  journal_id integer NOT NULL REFERENCES ledger_journals(id),
+  account_id integer NOT NULL REFERENCES ledger_accounts(id),
+  amount integer NOT NULL
+);
+
+CREATE INDEX idx_ledger_postings_journal_id ON ledger_postings(journal_id);
+CREATE INDEX idx_ledger_postings_account_id ON ledger_postings(account_id);
+
+-- Journals and postings are append-only, mistakes are corrected with a new journal
+CREATE TRIGGER ledger_journals_append_only_update BEFORE UPDATE ON ledger_journals
+BEGIN
+  SELECT RAISE(ABORT, 'ledger_journals is append-only');
+END;
+
+CREATE TRIGGER ledger_journals_append_only_delete BEFORE DELETE ON ledger_journals
+BEGIN
+  SELECT RAISE(ABORT, 'ledger_journals is append-only');
+END;
+
+CREATE TRIGGER ledger_postings_append_only_update BEFORE UPDATE ON ledger_postings
+BEGIN
+  SELECT RAISE(ABORT, 'ledger_postings is append-only');
+END;
+
+CREATE TRIGGER ledger_postings_append_only_delete BEFORE DELETE ON ledger_postings
+BEGIN
+  SELECT RAISE(ABORT, 'ledger_postings is append-only');
+END;
+
+-- Wallets that existed before the ledger get an opening balance against equity
+INSERT INTO ledger_accounts (code, kind, chat_id, created_at)
+SELECT 'wallet:' || chat_id, 'liability', chat_id, CURRENT_TIMESTAMP
+FROM wallet_entries
+GROUP BY chat_id;
+
+INSERT INTO ledger_accounts (code, kind, created_at)
+SELECT 'equity', 'equity', CURRENT_TIMESTAMP
+WHERE EXISTS (SELECT 1 FROM ledger_accounts);
+
+INSERT INTO ledger_journals (kind, ref_id, memo, created_at)
+SELECT 'opening', 'wallets', 'Opening balance of existing wallets', CURRENT_TIMESTAMP
+WHERE EXISTS (SELECT 1 FROM ledger_accounts);
+
+INSERT INTO ledger_postings (journal_id, account_id, amount)
+SELECT
+  (SELECT id FROM ledger_journals WHERE kind = 'opening' AND ref_id = 'wallets'),
+  a.id,
+  CASE
+    WHEN a.code = 'equity' THEN (SELECT SUM(e.amount) FROM wallet_entries e)
+    ELSE -(SELECT SUM(e.amount) FROM wallet_entries e WHERE e.chat_id = a.chat_id)
+  END
+FROM ledger_accounts a;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE ledger_postings;
+DROP TABLE ledger_journals;
+DROP TABLE ledger_accounts;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- api_keys authenticate the REST API. Only the SHA-256 of a key is stored,
+-- the key itself is shown once when it is created.
+CREATE TABLE api_keys (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  name text NOT NULL,
+  key_hash text NOT NULL,
+  prefix text NOT NULL,
+  scopes text NOT NULL,
+  created_at datetime NOT NULL,
+  last_used_at datetime,
+  revoked_at datetime
+);
+
+CREATE UNIQUE INDEX idx_api_keys_key_hash ON api_keys(key_hash);
+
+CREATE INDEX idx_transactions_created_at ON transactions(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_transactions_created_at;
+DROP TABLE api_keys;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- whatsapp_menus holds the reply IDs of the last numbered menu sent to a
+-- WhatsApp chat, so a number typed back still picks from it after a restart
+CREATE TABLE whatsapp_menus (
+  id integer PRIMARY KEY,
+  reply_ids json NOT NULL,
+  updated_at datetime NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE whatsapp_menus;
+-- +goose StatementEnd
@@ -8,15 +8,58 @@ import (
 	"database/sql"
 )
 
+type ApiKey struct {
+	ID         int64
+	Name       string
+	KeyHash    string
+	Prefix     string
+	Scopes     string
+	CreatedAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+}
+
 type Chat struct {
-	ID           int64
-	Command      string
-	Step         int64
-	Data         []byte
-	ReplyMarkup1 []byte
-	ReplyMarkup2 []byte
-	ReplyMarkup3 []byte
-	ReplyMarkup4 []byte
+	ID        int64
+	Command   string
+	State     string
+	Data      []byte
+	Stack     []byte
+	UpdatedAt sql.NullTime
+	ExpiresAt sql.NullTime
+}
+
+type LedgerAccount struct {
+	ID        int64
+	Code      string
+	Kind      string
+	ChatID    *int64
+	CreatedAt sql.NullTime
+}
+
+type LedgerJournal struct {
+	ID        int64
+	Kind      string
+	RefID     string
+	Memo      *string
+	CreatedAt sql.NullTime
+}
+
+type LedgerPosting struct {
+	ID        int64
+	JournalID int64
+	AccountID int64
+	Amount    int64
+}
+
+type PlnToken struct {
+	RefID        string
+	Token        string
+	CustomerName *string
+	Tariff       *string
+	Power        *string
+	Kwh          *string
+	CreatedAt    sql.NullTime
 }
 
 type PrepaidProduct struct {
@@ -38,6 +81,34 @@ type PrepaidProduct struct {
 	Description         *string
 }
 
+type Transaction struct {
+	ID           int64
+	RefID        string
+	ChatID       int64
+	BuyerSkuCode string
+	CustomerNo   string
+	Price        int64
+	Status       string
+	Rc           *string
+	Sn           *string
+	Message      *string
+	CreatedAt    sql.NullTime
+	UpdatedAt    sql.NullTime
+}
+
+type TransactionApproval struct {
+	ID           int64
+	ChatID       int64
+	BuyerSkuCode string
+	CustomerNo   string
+	Price        int64
+	Reason       string
+	Status       string
+	DecidedBy    *int64
+	CreatedAt    sql.NullTime
+	DecidedAt    sql.NullTime
+}
+
 type User struct {
 	ID        int64
 	Username  *string
@@ -45,3 +116,51 @@ type User struct {
 	LastName  *string
 	CreatedAt sql.NullTime
 }
+
+type UserLimit struct {
+	ID            int64
+	MaxTrxPrice   int64
+	MaxDailyTotal int64
+	MaxDailyCount int64
+	UpdatedAt     sql.NullTime
+}
+
+type UserPin struct {
+	ID             int64
+	PinHash        string
+	FailedAttempts int64
+	LockedUntil    sql.NullTime
+	UpdatedAt      sql.NullTime
+}
+
+type UserRole struct {
+	ID        int64
+	Role      string
+	UpdatedBy *int64
+	UpdatedAt sql.NullTime
+}
+
+type WalletEntry struct {
+	ID        int64
+	ChatID    int64
+	Amount    int64
+	Kind      string
+	RefID     string
+	CreatedAt sql.NullTime
+}
+
+type WalletTopup struct {
+	ID        int64
+	ChatID    int64
+	Amount    int64
+	Status    string
+	DecidedBy *int64
+	CreatedAt sql.NullTime
+	DecidedAt sql.NullTime
+}
+
+type WhatsappMenu struct {
+	ID        int64
+	ReplyIds  []byte
+	UpdatedAt sql.NullTime
+}
@@ -76,42 +76,30 @@ func (q *Queries) GetCategories(ctx context.Context) ([]string, error) {
 }
 
 const getPrepaidProductBySKUCode = `-- name: GetPrepaidProductBySKUCode :one
-SELECT 
-  id,
-  name,
-  seller_name,
-  price,
-  buyer_sku_code,
-  buyer_product_status,
-  seller_product_status,
-  description
-FROM prepaid_products
+SELECT id, name, category, brand, type, seller_name, price, buyer_sku_code, buyer_product_status, seller_product_status, unlimited_stock, stock, multi, start_cut_off, end_cut_off, description FROM prepaid_products
 WHERE buyer_sku_code = ? COLLATE NOCASE
 LIMIT 1
 `
 
-type GetPrepaidProductBySKUCodeRow struct {
-	ID                  int64
-	Name                string
-	SellerName          string
-	Price               int64
-	BuyerSkuCode        string
-	BuyerProductStatus  bool
-	SellerProductStatus bool
-	Description         *string
-}
-
-func (q *Queries) GetPrepaidProductBySKUCode(ctx context.Context, buyerSkuCode string) (*GetPrepaidProductBySKUCodeRow, error) {
+func (q *Queries) GetPrepaidProductBySKUCode(ctx context.Context, buyerSkuCode string) (*PrepaidProduct, error) {
 	row := q.queryRow(ctx, q.getPrepaidProductBySKUCodeStmt, getPrepaidProductBySKUCode, buyerSkuCode)
-	var i GetPrepaidProductBySKUCodeRow
+	var i PrepaidProduct
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
+		&i.Category,
+		&i.Brand,
+		&i.Type,
 		&i.SellerName,
 		&i.Price,
 		&i.BuyerSkuCode,
 		&i.BuyerProductStatus,
 		&i.SellerProductStatus,
+		&i.UnlimitedStock,
+		&i.Stock,
+		&i.Multi,
+		&i.StartCutOff,
+		&i.EndCutOff,
 		&i.Description,
 	)
 	return &i, err
@@ -124,7 +112,11 @@ SELECT
   pp.price,
   pp.seller_name,
   pp.buyer_product_status,
-  pp.seller_product_status
+  pp.seller_product_status,
+  pp.unlimited_stock,
+  pp.stock,
+  pp.start_cut_off,
+  pp.end_cut_off
 FROM prepaid_products pp
 WHERE pp.category = ?
   AND pp.brand = ?
@@ -145,6 +137,10 @@ type GetPrepaidProductsRow struct {
 	SellerName          string
 	BuyerProductStatus  bool
 	SellerProductStatus bool
+	UnlimitedStock      bool
+	Stock               int64
+	StartCutOff         *string
+	EndCutOff           *string
 }
 
 func (q *Queries) GetPrepaidProducts(ctx context.Context, arg *GetPrepaidProductsParams) ([]*GetPrepaidProductsRow, error) {
@@ -163,6 +159,10 @@ func (q *Queries) GetPrepaidProducts(ctx context.Context, arg *GetPrepaidProduct
 			&i.SellerName,
 			&i.BuyerProductStatus,
 			&i.SellerProductStatus,
+			&i.UnlimitedStock,
+			&i.Stock,
+			&i.StartCutOff,
+			&i.EndCutOff,
 		); err != nil {
 			return nil, err
 		}
@@ -270,3 +270,135 @@ func (q *Queries) InsertPrepaidProduct(ctx context.Context, arg *InsertPrepaidPr
 	)
 	return err
 }
+
+const listEquivalentPrepaidProducts = `-- name: ListEquivalentPrepaidProducts :many
+SELECT p.id, p.name, p.category, p.brand, p.type, p.seller_name, p.price, p.buyer_sku_code, p.buyer_product_status, p.seller_product_status, p.unlimited_stock, p.stock, p.multi, p.start_cut_off, p.end_cut_off, p.description FROM prepaid_products p
+JOIN prepaid_products o ON o.buyer_sku_code = ?1 COLLATE NOCASE
+WHERE p.id != o.id
+  AND p.category = o.category
+  AND p.brand = o.brand
+  AND p.type = o.type
+  AND p.name = o.name COLLATE NOCASE
+  AND p.multi = o.multi
+  AND p.buyer_product_status = 1
+  AND p.seller_product_status = 1
+  AND p.price <= o.price + ?2
+ORDER BY p.price ASC, p.id ASC
+`
+
+type ListEquivalentPrepaidProductsParams struct {
+	BuyerSkuCode  string
+	MaxPriceDelta int64
+}
+
+// Active products from other sellers with the same category, brand, type, name (nominal) and multi
+func (q *Queries) ListEquivalentPrepaidProducts(ctx context.Context, arg *ListEquivalentPrepaidProductsParams) ([]*PrepaidProduct, error) {
+	rows, err := q.query(ctx, q.listEquivalentPrepaidProductsStmt, listEquivalentPrepaidProducts, arg.BuyerSkuCode, arg.MaxPriceDelta)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*PrepaidProduct{}
+	for rows.Next() {
+		var i PrepaidProduct
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Category,
+			&i.Brand,
+			&i.Type,
+			&i.SellerName,
+			&i.Price,
+			&i.BuyerSkuCode,
+			&i.BuyerProductStatus,
+			&i.SellerProductStatus,
+			&i.UnlimitedStock,
+			&i.Stock,
+			&i.Multi,
+			&i.StartCutOff,
+			&i.EndCutOff,
+			&i.Description,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPrepaidProducts = `-- name: SearchPrepaidProducts :many
+SELECT id, name, category, brand, type, seller_name, price, buyer_sku_code, buyer_product_status, seller_product_status, unlimited_stock, stock, multi, start_cut_off, end_cut_off, description FROM prepaid_products
+WHERE (?1 IS NULL OR category = ?1 COLLATE NOCASE)
+  AND (?2 IS NULL OR brand = ?2 COLLATE NOCASE)
+  AND (?3 IS NULL OR type = ?3 COLLATE NOCASE)
+  AND (
+    ?4 IS NULL
+    OR name LIKE '%' || ?4 || '%'
+    OR buyer_sku_code LIKE '%' || ?4 || '%'
+  )
+ORDER BY category, brand, type, price, id
+LIMIT ?5 OFFSET ?6
+`
+
+type SearchPrepaidProductsParams struct {
+	Category *string
+	Brand    *string
+	Type     *string
+	Search   *string
+	Limit    int64
+	Offset   int64
+}
+
+// Filters that are NULL are ignored. search matches the name or the SKU code.
+func (q *Queries) SearchPrepaidProducts(ctx context.Context, arg *SearchPrepaidProductsParams) ([]*PrepaidProduct, error) {
+	rows, err := q.query(ctx, q.searchPrepaidProductsStmt, searchPrepaidProducts,
+		arg.Category,
+		arg.Brand,
+		arg.Type,
+		arg.Search,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*PrepaidProduct{}
+	for rows.Next() {
+		var i PrepaidProduct
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Category,
+			&i.Brand,
+			&i.Type,
+			&i.SellerName,
+			&i.Price,
+			&i.BuyerSkuCode,
+			&i.BuyerProductStatus,
+			&i.SellerProductStatus,
+			&i.UnlimitedStock,
+			&i.Stock,
+			&i.Multi,
+			&i.StartCutOff,
+			&i.EndCutOff,
+			&i.Description,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,23 @@
+-- name: CreateApiKey :one
+INSERT INTO api_keys (name, key_hash, prefix, scopes, created_at)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetActiveApiKeyByHash :one
+SELECT * FROM api_keys
+WHERE key_hash = ? AND revoked_at IS NULL
+LIMIT 1;
+
+-- name: ListApiKeys :many
+SELECT * FROM api_keys
+ORDER BY id;
+
+-- name: TouchApiKey :exec
+UPDATE api_keys
+SET last_used_at = ?
+WHERE id = ?;
+
+-- name: RevokeApiKey :execrows
+UPDATE api_keys
+SET revoked_at = ?
+WHERE id = ? AND revoked_at IS NULL;
@@ -1,46 +1,30 @@
--- name: IsChatExists :one
-SELECT EXISTS(SELECT 1 FROM chats WHERE id = ? LIMIT 1);
-
 -- name: GetChat :one
 SELECT * FROM chats WHERE id = ? LIMIT 1;
 
--- name: CreateChat :one
-INSERT INTO chats (id, command, step, data) 
-VALUES (?, ?, ?, ?) 
-RETURNING *;
-
--- name: UpdateChat :one
-UPDATE chats
-SET 
-  command = ?, 
-  step = ?, 
-  data = ?
-WHERE id = ?
+-- name: UpsertChat :one
+INSERT INTO chats (id, command, state, data, stack, updated_at, expires_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+  command = excluded.command,
+  state = excluded.state,
+  data = excluded.data,
+  stack = excluded.stack,
+  updated_at = excluded.updated_at,
+  expires_at = excluded.expires_at
 RETURNING *;
 
--- name: UpdateReplyMarkup1 :exec
-UPDATE chats
-SET 
-  reply_markup_1 = ?
-WHERE id = ?;
-
--- name: UpdateReplyMarkup2 :exec
-UPDATE chats
-SET 
-  reply_markup_2 = ?
-WHERE id = ?;
+-- name: DeleteChat :exec
+DELETE FROM chats WHERE id = ?;
 
--- name: UpdateReplyMarkup3 :exec
-UPDATE chats
-SET 
-  reply_markup_3 = ?
-WHERE id = ?;
+-- name: DeleteChatByCommand :exec
+DELETE FROM chats WHERE id = ? AND command = ?;
 
--- name: UpdateReplyMarkup4 :exec
-UPDATE chats
-SET 
-  reply_markup_4 = ?
-WHERE id = ?;
+-- name: ClaimChat :one
+DELETE FROM chats
+WHERE id = ? AND command = ? AND state = ?
+RETURNING *;
 
--- name: DeleteChat :exec
-DELETE FROM chats WHERE id = ?;
\ No newline at end of file
+-- name: DeleteExpiredChats :many
+DELETE FROM chats
+WHERE expires_at IS NOT NULL AND expires_at < ?
+RETURNING *;
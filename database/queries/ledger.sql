@@ -0,0 +1,89 @@
+-- name: GetLedgerAccount :one
+SELECT * FROM ledger_accounts
+WHERE code = ?
+LIMIT 1;
+
+-- name: ListLedgerAccounts :many
+-- The balance of an account is the sum of its postings
+SELECT
+  a.id,
+  a.code,
+  a.kind,
+  a.chat_id,
+  a.created_at,
+  CAST(COALESCE(SUM(p.amount), 0) AS INTEGER) AS balance
+FROM ledger_accounts a
+LEFT JOIN ledger_postings p ON p.account_id = a.id
+GROUP BY a.id
+ORDER BY a.code;
+
+-- name: UpsertLedgerAccount :one
+INSERT INTO ledger_accounts (code, kind, chat_id, created_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (code) DO UPDATE SET code = excluded.code
+RETURNING *;
+
+-- name: GetLedgerJournal :one
+SELECT * FROM ledger_journals
+WHERE kind = ? AND ref_id = ?
+LIMIT 1;
+
+-- name: CreateLedgerJournal :one
+INSERT INTO ledger_journals (kind, ref_id, memo, created_at)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: CreateLedgerPosting :exec
+INSERT INTO ledger_postings (journal_id, account_id, amount)
+VALUES (?, ?, ?);
+
+-- name: GetLedgerJournalAccountAmount :one
+-- The amount a journal posted to an account
+SELECT CAST(COALESCE(SUM(p.amount), 0) AS INTEGER) AS amount
+FROM ledger_postings p
+JOIN ledger_journals j ON j.id = p.journal_id
+JOIN ledger_accounts a ON a.id = p.account_id
+WHERE j.kind = ? AND j.ref_id = ? AND a.code = ?;
+
+-- name: ListLedgerPostings :many
+SELECT * FROM ledger_postings
+WHERE journal_id = ?
+ORDER BY id;
+
+-- name: CountLedgerJournals :one
+SELECT COUNT(*) FROM ledger_journals;
+
+-- name: ListUnbalancedLedgerJournals :many
+-- A journal must have at least two postings summing to zero
+SELECT
+  j.id,
+  j.kind,
+  j.ref_id,
+  CAST(COALESCE(SUM(p.amount), 0) AS INTEGER) AS total,
+  COUNT(p.id) AS postings
+FROM ledger_journals j
+LEFT JOIN ledger_postings p ON p.journal_id = j.id
+GROUP BY j.id
+HAVING total != 0 OR postings < 2
+ORDER BY j.id;
+
+-- name: ListWalletLedgerDrifts :many
+-- Wallets whose entries disagree with their ledger account
+SELECT
+  c.chat_id,
+  CAST((
+    SELECT COALESCE(SUM(e.amount), 0) FROM wallet_entries e WHERE e.chat_id = c.chat_id
+  ) AS INTEGER) AS wallet_balance,
+  CAST((
+    SELECT COALESCE(-SUM(p.amount), 0)
+    FROM ledger_postings p
+    JOIN ledger_accounts a ON a.id = p.account_id
+    WHERE a.code = 'wallet:' || c.chat_id
+  ) AS INTEGER) AS ledger_balance
+FROM (
+  SELECT chat_id FROM wallet_entries
+  UNION
+  SELECT chat_id FROM ledger_accounts WHERE chat_id IS NOT NULL
+) c
+WHERE wallet_balance != ledger_balance
+ORDER BY c.chat_id;
@@ -0,0 +1,12 @@
+-- name: GetPLNTokenByRefID :one
+SELECT * FROM pln_tokens WHERE ref_id = ? LIMIT 1;
+
+-- name: UpsertPLNToken :exec
+INSERT INTO pln_tokens (ref_id, token, customer_name, tariff, power, kwh, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (ref_id) DO UPDATE SET
+  token = excluded.token,
+  customer_name = excluded.customer_name,
+  tariff = excluded.tariff,
+  power = excluded.power,
+  kwh = excluded.kwh;
@@ -39,7 +39,11 @@ SELECT
   pp.price,
   pp.seller_name,
   pp.buyer_product_status,
-  pp.seller_product_status
+  pp.seller_product_status,
+  pp.unlimited_stock,
+  pp.stock,
+  pp.start_cut_off,
+  pp.end_cut_off
 FROM prepaid_products pp
 WHERE pp.category = ?
   AND pp.brand = ?
@@ -47,18 +51,38 @@ WHERE pp.category = ?
 ORDER BY pp.price ASC;
 
 -- name: GetPrepaidProductBySKUCode :one
-SELECT 
-  id,
-  name,
-  seller_name,
-  price,
-  buyer_sku_code,
-  buyer_product_status,
-  seller_product_status,
-  description
-FROM prepaid_products
+SELECT * FROM prepaid_products
 WHERE buyer_sku_code = ? COLLATE NOCASE
 LIMIT 1;
 
 -- name: DeleteAllPrepaidProducts :exec
-DELETE FROM prepaid_products;
\ No newline at end of file
+DELETE FROM prepaid_products;
+
+-- name: ListEquivalentPrepaidProducts :many
+-- Active products from other sellers with the same category, brand, type, name (nominal) and multi
+SELECT p.* FROM prepaid_products p
+JOIN prepaid_products o ON o.buyer_sku_code = sqlc.arg(buyer_sku_code) COLLATE NOCASE
+WHERE p.id != o.id
+  AND p.category = o.category
+  AND p.brand = o.brand
+  AND p.type = o.type
+  AND p.name = o.name COLLATE NOCASE
+  AND p.multi = o.multi
+  AND p.buyer_product_status = 1
+  AND p.seller_product_status = 1
+  AND p.price <= o.price + sqlc.arg(max_price_delta)
+ORDER BY p.price ASC, p.id ASC;
+
+-- name: SearchPrepaidProducts :many
+-- Filters that are NULL are ignored. search matches the name or the SKU code.
+SELECT * FROM prepaid_products
+WHERE (sqlc.narg(category) IS NULL OR category = sqlc.narg(category) COLLATE NOCASE)
+  AND (sqlc.narg(brand) IS NULL OR brand = sqlc.narg(brand) COLLATE NOCASE)
+  AND (sqlc.narg(type) IS NULL OR type = sqlc.narg(type) COLLATE NOCASE)
+  AND (
+    sqlc.narg(search) IS NULL
+    OR name LIKE '%' || sqlc.narg(search) || '%'
+    OR buyer_sku_code LIKE '%' || sqlc.narg(search) || '%'
+  )
+ORDER BY category, brand, type, price, id
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
@@ -0,0 +1,10 @@
+-- name: InsertTelegramUpdate :execrows
+INSERT INTO telegram_updates (id, created_at)
+VALUES (?, ?)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: DeleteTelegramUpdate :exec
+DELETE FROM telegram_updates WHERE id = ?;
+
+-- name: DeleteTelegramUpdatesBefore :execrows
+DELETE FROM telegram_updates WHERE created_at < ?;
@@ -0,0 +1,21 @@
+-- name: CreateTransactionApproval :one
+INSERT INTO transaction_approvals (
+  chat_id,
+  buyer_sku_code,
+  customer_no,
+  price,
+  reason,
+  status,
+  created_at
+)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: DecideTransactionApproval :one
+UPDATE transaction_approvals
+SET
+  status = ?,
+  decided_by = ?,
+  decided_at = ?
+WHERE id = ? AND status = 'pending'
+RETURNING *;
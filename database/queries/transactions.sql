@@ -0,0 +1,65 @@
+-- name: CreateTransaction :one
+INSERT INTO transactions (
+  ref_id,
+  chat_id,
+  buyer_sku_code,
+  customer_no,
+  price,
+  status,
+  created_at,
+  updated_at
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: UpdateTransactionByRefID :exec
+UPDATE transactions
+SET
+  price = ?,
+  status = ?,
+  rc = ?,
+  sn = ?,
+  message = ?,
+  updated_at = ?
+WHERE ref_id = ?;
+
+-- name: GetDailyTransactionSummary :one
+SELECT
+  CAST(COALESCE(SUM(price), 0) AS INTEGER) AS total,
+  COUNT(*) AS count
+FROM transactions
+WHERE chat_id = ?
+  AND status != 'Gagal'
+  AND created_at >= ?;
+
+-- name: GetLatestTransactionBySKUAndCustomerNo :one
+SELECT * FROM transactions
+WHERE buyer_sku_code = ? COLLATE NOCASE
+  AND customer_no = ?
+  AND created_at >= ?
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: GetTransactionByRefID :one
+SELECT * FROM transactions
+WHERE ref_id = ?;
+
+-- name: ListPendingTransactions :many
+-- Transactions updated recently may still be waiting for Digiflazz to answer
+SELECT * FROM transactions
+WHERE status = 'Pending'
+  AND created_at >= sqlc.arg(created_after)
+  AND updated_at < sqlc.arg(updated_before)
+ORDER BY id ASC
+LIMIT 100;
+
+-- name: ListTransactions :many
+-- Filters that are NULL are ignored
+SELECT * FROM transactions
+WHERE (sqlc.narg(chat_id) IS NULL OR chat_id = sqlc.narg(chat_id))
+  AND (sqlc.narg(status) IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(customer_no) IS NULL OR customer_no = sqlc.narg(customer_no))
+  AND (sqlc.narg(created_after) IS NULL OR created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before) IS NULL OR created_at < sqlc.narg(created_before))
+ORDER BY id DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
@@ -0,0 +1,15 @@
+-- name: GetUserLimit :one
+SELECT * FROM user_limits WHERE id = ? LIMIT 1;
+
+-- name: UpsertUserLimit :one
+INSERT INTO user_limits (id, max_trx_price, max_daily_total, max_daily_count, updated_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+  max_trx_price = excluded.max_trx_price,
+  max_daily_total = excluded.max_daily_total,
+  max_daily_count = excluded.max_daily_count,
+  updated_at = excluded.updated_at
+RETURNING *;
+
+-- name: DeleteUserLimit :exec
+DELETE FROM user_limits WHERE id = ?;
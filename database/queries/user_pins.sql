@@ -0,0 +1,38 @@
+-- name: GetUserPin :one
+SELECT * FROM user_pins WHERE id = ? LIMIT 1;
+
+-- name: UpsertUserPin :exec
+INSERT INTO user_pins (id, pin_hash, failed_attempts, locked_until, updated_at)
+VALUES (?, ?, 0, NULL, ?)
+ON CONFLICT (id) DO UPDATE SET
+  pin_hash = excluded.pin_hash,
+  failed_attempts = 0,
+  locked_until = NULL,
+  updated_at = excluded.updated_at;
+
+-- name: DeleteUserPin :exec
+DELETE FROM user_pins WHERE id = ?;
+
+-- name: IncrementUserPinFailedAttempts :one
+UPDATE user_pins
+SET
+  failed_attempts = failed_attempts + 1,
+  updated_at = ?
+WHERE id = ?
+RETURNING failed_attempts;
+
+-- name: LockUserPin :exec
+UPDATE user_pins
+SET
+  failed_attempts = 0,
+  locked_until = ?,
+  updated_at = ?
+WHERE id = ?;
+
+-- name: ResetUserPinFailedAttempts :exec
+UPDATE user_pins
+SET
+  failed_attempts = 0,
+  locked_until = NULL,
+  updated_at = ?
+WHERE id = ?;
@@ -0,0 +1,16 @@
+-- name: GetUserRole :one
+SELECT * FROM user_roles WHERE id = ? LIMIT 1;
+
+-- name: ListUserRoles :many
+SELECT * FROM user_roles ORDER BY id;
+
+-- name: UpsertUserRole :exec
+INSERT INTO user_roles (id, role, updated_by, updated_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+  role = excluded.role,
+  updated_by = excluded.updated_by,
+  updated_at = excluded.updated_at;
+
+-- name: DeleteUserRole :exec
+DELETE FROM user_roles WHERE id = ?;
@@ -7,4 +7,7 @@ SELECT EXISTS(SELECT 1 FROM users WHERE id = ? LIMIT 1);
 -- name: CreateUser :one
 INSERT INTO users (id, username, first_name, last_name, created_at) 
 VALUES (?, ?, ?, ?, ?) 
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: ListUsers :many
+SELECT * FROM users ORDER BY id;
@@ -0,0 +1,50 @@
+-- name: GetWalletBalance :one
+SELECT CAST(COALESCE(SUM(amount), 0) AS INTEGER) AS balance
+FROM wallet_entries
+WHERE chat_id = ?;
+
+-- name: ListWalletEntries :many
+SELECT * FROM wallet_entries
+WHERE chat_id = ?
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: GetWalletEntry :one
+SELECT * FROM wallet_entries
+WHERE kind = ? AND ref_id = ?
+LIMIT 1;
+
+-- name: CreateWalletEntry :one
+INSERT INTO wallet_entries (chat_id, amount, kind, ref_id, created_at)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: DebitWallet :execrows
+-- The debit is only recorded if the balance covers it
+INSERT INTO wallet_entries (chat_id, amount, kind, ref_id, created_at)
+SELECT sqlc.arg(chat_id), -sqlc.arg(amount), sqlc.arg(kind), sqlc.arg(ref_id), sqlc.arg(created_at)
+WHERE (
+  SELECT COALESCE(SUM(amount), 0) FROM wallet_entries WHERE chat_id = sqlc.arg(chat_id)
+) >= sqlc.arg(amount);
+
+-- name: RefundWalletPurchase :one
+INSERT INTO wallet_entries (chat_id, amount, kind, ref_id, created_at)
+SELECT chat_id, -amount, 'refund', ref_id, ?
+FROM wallet_entries
+WHERE kind = 'purchase' AND ref_id = ?
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: CreateWalletTopup :one
+INSERT INTO wallet_topups (chat_id, amount, status, created_at)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: DecideWalletTopup :one
+UPDATE wallet_topups
+SET
+  status = ?,
+  decided_by = ?,
+  decided_at = ?
+WHERE id = ? AND status = 'pending'
+RETURNING *;
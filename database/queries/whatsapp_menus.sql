@@ -0,0 +1,12 @@
+-- name: GetWhatsAppMenu :one
+SELECT * FROM whatsapp_menus WHERE id = ? LIMIT 1;
+
+-- name: UpsertWhatsAppMenu :exec
+INSERT INTO whatsapp_menus (id, reply_ids, updated_at)
+VALUES (?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+  reply_ids = excluded.reply_ids,
+  updated_at = excluded.updated_at;
+
+-- name: DeleteWhatsAppMenu :exec
+DELETE FROM whatsapp_menus WHERE id = ?;
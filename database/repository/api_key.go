@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+// Scopes of API keys
+const (
+	APIScopeProductsRead      = "products:read"
+	APIScopeTransactionsRead  = "transactions:read"
+	APIScopeTransactionsWrite = "transactions:write"
+	APIScopeBalanceRead       = "balance:read"
+	APIScopeUsersRead         = "users:read"
+	APIScopeUsersWrite        = "users:write"
+)
+
+var APIScopes = []string{
+	APIScopeProductsRead,
+	APIScopeTransactionsRead,
+	APIScopeTransactionsWrite,
+	APIScopeBalanceRead,
+	APIScopeUsersRead,
+	APIScopeUsersWrite,
+}
+
+// apiKeyPrefix starts every key, so a leaked one is easy to spot
+const apiKeyPrefix = "dfb_"
+
+// APIChatID is recorded as the chat of transactions made with the API. No
+// chat has this ID, so they are never paid from a wallet or sent to a chat.
+const APIChatID int64 = 0
+
+// ErrAPIKeyNotFound is returned for unknown or revoked keys.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// CreateAPIKey creates a key with the given scopes. The key is returned only
+// here, the database keeps its hash.
+func CreateAPIKey(ctx context.Context, name string, scopes []string) (string, *database.ApiKey, error) {
+	for _, scope := range scopes {
+		if !slices.Contains(APIScopes, scope) {
+			return "", nil, fmt.Errorf("unknown scope '%s'", scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return "", nil, errors.New("at least one scope is required")
+	}
+
+	secret, err := util.GenerateSecretToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	key := apiKeyPrefix + secret
+
+	apiKey, err := database.Sqlc.CreateApiKey(ctx, &database.CreateApiKeyParams{
+		Name:      name,
+		KeyHash:   hashAPIKey(key),
+		Prefix:    key[:len(apiKeyPrefix)+6],
+		Scopes:    strings.Join(scopes, " "),
+		CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return key, apiKey, nil
+}
+
+// AuthenticateAPIKey returns the active key matching key and records its use.
+func AuthenticateAPIKey(ctx context.Context, key string) (*database.ApiKey, error) {
+	apiKey, err := database.Sqlc.GetActiveApiKeyByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	err = database.Sqlc.TouchApiKey(ctx, &database.TouchApiKeyParams{
+		LastUsedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:         apiKey.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+// RevokeAPIKey revokes a key. It returns ErrAPIKeyNotFound if there is no
+// active key with that ID.
+func RevokeAPIKey(ctx context.Context, id int64) error {
+	revoked, err := database.Sqlc.RevokeApiKey(ctx, &database.RevokeApiKeyParams{
+		RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:        id,
+	})
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// APIKeyHasScope reports whether the key was granted the scope.
+func APIKeyHasScope(apiKey *database.ApiKey, scope string) bool {
+	return slices.Contains(strings.Fields(apiKey.Scopes), scope)
+}
+
+// Keys are long and random, so unlike PINs a fast hash can't be brute-forced.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/google/uuid"
+)
+
+// Kinds of ledger accounts. Debits are positive, so assets and expenses
+// normally have a positive balance, liabilities, income and equity a negative one.
+const (
+	LedgerAsset     = "asset"
+	LedgerLiability = "liability"
+	LedgerIncome    = "income"
+	LedgerExpense   = "expense"
+	LedgerEquity    = "equity"
+)
+
+// Ledger accounts besides the per-customer wallets
+const (
+	// LedgerCash is the money customers paid for their top-ups
+	LedgerCash = "cash"
+	// LedgerDeposit is the Digiflazz deposit
+	LedgerDeposit = "deposit"
+	// LedgerRevenue is what customers paid for their purchases
+	LedgerRevenue = "revenue"
+	// LedgerCost is what Digiflazz charged for every successful purchase
+	LedgerCost = "cost"
+	// LedgerEquityAccount balances opening balances and adjustments
+	LedgerEquityAccount = "equity"
+)
+
+// Kinds of ledger journals
+const (
+	LedgerJournalTopup        = "topup"
+	LedgerJournalPurchase     = "purchase"
+	LedgerJournalRefund       = "refund"
+	LedgerJournalCost         = "cost"
+	LedgerJournalCostReversal = "cost_reversal"
+	LedgerJournalAdjustment   = "adjustment"
+)
+
+// ErrUnbalancedJournal is returned when the postings of a journal don't sum to zero.
+var ErrUnbalancedJournal = errors.New("ledger postings don't sum to zero")
+
+var ledgerAccountKinds = map[string]string{
+	LedgerCash:          LedgerAsset,
+	LedgerDeposit:       LedgerAsset,
+	LedgerRevenue:       LedgerIncome,
+	LedgerCost:          LedgerExpense,
+	LedgerEquityAccount: LedgerEquity,
+}
+
+// LedgerWalletAccount is the account of a customer's wallet.
+func LedgerWalletAccount(chatId int64) string {
+	return fmt.Sprintf("wallet:%d", chatId)
+}
+
+type LedgerPosting struct {
+	Account string
+	Amount  int64
+}
+
+// postLedger records a journal and its postings. It must run inside the
+// caller's database transaction.
+func postLedger(ctx context.Context, q *database.Queries, kind, refId, memo string, postings []LedgerPosting) error {
+	var total int64
+	for _, p := range postings {
+		total += p.Amount
+	}
+	if total != 0 || len(postings) < 2 {
+		return ErrUnbalancedJournal
+	}
+
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	journal, err := q.CreateLedgerJournal(ctx, &database.CreateLedgerJournalParams{
+		Kind:      kind,
+		RefID:     refId,
+		Memo:      nullableString(memo),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range postings {
+		account, err := upsertLedgerAccount(ctx, q, p.Account, now)
+		if err != nil {
+			return err
+		}
+		err = q.CreateLedgerPosting(ctx, &database.CreateLedgerPostingParams{
+			JournalID: journal.ID,
+			AccountID: account.ID,
+			Amount:    p.Amount,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsertLedgerAccount returns the account, creating it on first use.
+func upsertLedgerAccount(ctx context.Context, q *database.Queries, code string, now sql.NullTime) (*database.LedgerAccount, error) {
+	params := &database.UpsertLedgerAccountParams{
+		Code:      code,
+		Kind:      ledgerAccountKinds[code],
+		CreatedAt: now,
+	}
+	if chatIdStr, ok := strings.CutPrefix(code, "wallet:"); ok {
+		chatId, err := strconv.ParseInt(chatIdStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ledger account %q", code)
+		}
+		params.Kind = LedgerLiability
+		params.ChatID = &chatId
+	}
+	if params.Kind == "" {
+		return nil, fmt.Errorf("unknown ledger account %q", code)
+	}
+	return q.UpsertLedgerAccount(ctx, params)
+}
+
+// postLedgerTopup moves an approved top-up into the customer's wallet.
+func postLedgerTopup(ctx context.Context, q *database.Queries, topup *database.WalletTopup) error {
+	return postLedger(ctx, q, LedgerJournalTopup, strconv.FormatInt(topup.ID, 10), "", []LedgerPosting{
+		{Account: LedgerCash, Amount: topup.Amount},
+		{Account: LedgerWalletAccount(topup.ChatID), Amount: -topup.Amount},
+	})
+}
+
+// postLedgerPurchase records a purchase paid from a wallet: the selling price
+// is earned. What Digiflazz charges is posted by postLedgerCost once it succeeds.
+func postLedgerPurchase(ctx context.Context, q *database.Queries, arg *CreateWalletPurchaseParams) error {
+	return postLedger(ctx, q, LedgerJournalPurchase, arg.RefID, "", []LedgerPosting{
+		{Account: LedgerWalletAccount(arg.ChatID), Amount: arg.SellingPrice},
+		{Account: LedgerRevenue, Amount: -arg.SellingPrice},
+	})
+}
+
+// postLedgerCost spends the price Digiflazz charged for a successful
+// transaction from the deposit. It is posted once per transaction, whoever made it.
+func postLedgerCost(ctx context.Context, q *database.Queries, refId string, price int64) error {
+	_, err := q.GetLedgerJournal(ctx, &database.GetLedgerJournalParams{
+		Kind:  LedgerJournalCost,
+		RefID: refId,
+	})
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// Purchases journaled before costs were posted on success include the
+	// catalogue price already
+	posted, err := q.GetLedgerJournalAccountAmount(ctx, &database.GetLedgerJournalAccountAmountParams{
+		Kind:  LedgerJournalPurchase,
+		RefID: refId,
+		Code:  LedgerCost,
+	})
+	if err != nil {
+		return err
+	}
+	if price == posted {
+		return nil
+	}
+
+	return postLedger(ctx, q, LedgerJournalCost, refId, "", []LedgerPosting{
+		{Account: LedgerCost, Amount: price - posted},
+		{Account: LedgerDeposit, Amount: posted - price},
+	})
+}
+
+// reverseLedgerCost reverses the cost journal of a transaction that failed
+// after it succeeded, since Digiflazz returns the price to the deposit.
+func reverseLedgerCost(ctx context.Context, q *database.Queries, refId string) error {
+	cost, err := q.GetLedgerJournal(ctx, &database.GetLedgerJournalParams{
+		Kind:  LedgerJournalCost,
+		RefID: refId,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = q.GetLedgerJournal(ctx, &database.GetLedgerJournalParams{
+		Kind:  LedgerJournalCostReversal,
+		RefID: refId,
+	})
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	return reverseLedgerJournal(ctx, q, cost, LedgerJournalCostReversal)
+}
+
+// postLedgerRefund reverses the purchase journal of a failed transaction.
+func postLedgerRefund(ctx context.Context, q *database.Queries, refund *database.WalletEntry) error {
+	purchase, err := q.GetLedgerJournal(ctx, &database.GetLedgerJournalParams{
+		Kind:  LedgerJournalPurchase,
+		RefID: refund.RefID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Bought before the ledger, the wallet is in the opening balance
+		return postLedger(ctx, q, LedgerJournalRefund, refund.RefID, "", []LedgerPosting{
+			{Account: LedgerWalletAccount(refund.ChatID), Amount: -refund.Amount},
+			{Account: LedgerEquityAccount, Amount: refund.Amount},
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return reverseLedgerJournal(ctx, q, purchase, LedgerJournalRefund)
+}
+
+// reverseLedgerJournal records a journal of the given kind with the opposite
+// postings of the journal.
+func reverseLedgerJournal(ctx context.Context, q *database.Queries, journal *database.LedgerJournal, kind string) error {
+	postings, err := q.ListLedgerPostings(ctx, journal.ID)
+	if err != nil {
+		return err
+	}
+
+	reversal, err := q.CreateLedgerJournal(ctx, &database.CreateLedgerJournalParams{
+		Kind:      kind,
+		RefID:     journal.RefID,
+		CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range postings {
+		err = q.CreateLedgerPosting(ctx, &database.CreateLedgerPostingParams{
+			JournalID: reversal.ID,
+			AccountID: p.AccountID,
+			Amount:    -p.Amount,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AdjustLedger corrects the balance of an account against equity, e.g. after
+// adding funds to the Digiflazz deposit. Wallets only change through their
+// entries, so they can't be adjusted here.
+func AdjustLedger(ctx context.Context, account string, amount int64, memo string) error {
+	if strings.HasPrefix(account, "wallet:") {
+		return errors.New("wallet accounts can't be adjusted")
+	}
+	if amount == 0 {
+		return errors.New("amount must not be zero")
+	}
+
+	tx, err := database.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := database.Sqlc.WithTx(tx)
+	err = postLedger(ctx, qtx, LedgerJournalAdjustment, uuid.Must(uuid.NewV7()).String(), memo, []LedgerPosting{
+		{Account: account, Amount: amount},
+		{Account: LedgerEquityAccount, Amount: -amount},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type LedgerReport struct {
+	Journals           int64
+	Accounts           []*database.ListLedgerAccountsRow
+	UnbalancedJournals []*database.ListUnbalancedLedgerJournalsRow
+	WalletDrifts       []*database.ListWalletLedgerDriftsRow
+}
+
+// Inconsistencies is the number of problems found.
+func (r *LedgerReport) Inconsistencies() int {
+	return len(r.UnbalancedJournals) + len(r.WalletDrifts)
+}
+
+// VerifyLedger sums the balances from the postings, checks that every journal
+// balances and compares the wallet accounts with the wallet entries.
+func VerifyLedger(ctx context.Context) (*LedgerReport, error) {
+	var r LedgerReport
+	var err error
+
+	r.Journals, err = database.Sqlc.CountLedgerJournals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.Accounts, err = database.Sqlc.ListLedgerAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.UnbalancedJournals, err = database.Sqlc.ListUnbalancedLedgerJournals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.WalletDrifts, err = database.Sqlc.ListWalletLedgerDrifts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
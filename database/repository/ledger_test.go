@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+)
+
+// TestMain runs the tests against a fresh, migrated database.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "repository")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	config.Cfg = &config.Config{DatabaseURL: filepath.Join(dir, "db.sqlite")}
+	database.MustLoadDatabase(context.Background())
+
+	code := m.Run()
+	database.DBConn.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+var testChatId int64 = 1000
+
+// newTestChat returns a chat ID no other test uses.
+func newTestChat() int64 {
+	testChatId++
+	return testChatId
+}
+
+func testTopup(t *testing.T, chatId, amount int64) {
+	t.Helper()
+	ctx := context.Background()
+	topup, err := database.Sqlc.CreateWalletTopup(ctx, &database.CreateWalletTopupParams{
+		ChatID:    chatId,
+		Amount:    amount,
+		Status:    WalletTopupPending,
+		CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = DecideWalletTopup(ctx, topup.ID, WalletTopupApproved, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testPurchase(t *testing.T, refId string, chatId, price, sellingPrice int64) {
+	t.Helper()
+	err := CreateWalletPurchase(context.Background(), &CreateWalletPurchaseParams{
+		RefID:        refId,
+		ChatID:       chatId,
+		BuyerSkuCode: "sku",
+		CustomerNo:   "08123",
+		Price:        price,
+		SellingPrice: sellingPrice,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testUpdate(t *testing.T, refId string, status types.DigiflazzTrxStatus, price int64) {
+	t.Helper()
+	err := UpdateTransaction(context.Background(), &UpdateTransactionParams{
+		RefID:  refId,
+		Price:  price,
+		Status: string(status),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ledgerBalances returns the balance of every ledger account.
+func ledgerBalances(t *testing.T) map[string]int64 {
+	t.Helper()
+	accounts, err := database.Sqlc.ListLedgerAccounts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	balances := make(map[string]int64)
+	for _, a := range accounts {
+		balances[a.Code] = a.Balance
+	}
+	return balances
+}
+
+func TestLedgerPostings(t *testing.T) {
+	tests := []struct {
+		name string
+		// run changes the ledger for a new chat
+		run func(t *testing.T, chatId int64)
+		// want holds the change of each account, "wallet" is the chat's
+		want map[string]int64
+	}{
+		{
+			name: "approved topup",
+			run: func(t *testing.T, chatId int64) {
+				testTopup(t, chatId, 10000)
+			},
+			want: map[string]int64{"wallet": -10000, LedgerCash: 10000},
+		},
+		{
+			name: "pending purchase",
+			run: func(t *testing.T, chatId int64) {
+				testTopup(t, chatId, 10000)
+				testPurchase(t, fmt.Sprintf("pending-%d", chatId), chatId, 5000, 5500)
+			},
+			want: map[string]int64{"wallet": -4500, LedgerCash: 10000, LedgerRevenue: -5500},
+		},
+		{
+			name: "successful purchase",
+			run: func(t *testing.T, chatId int64) {
+				refId := fmt.Sprintf("success-%d", chatId)
+				testTopup(t, chatId, 10000)
+				testPurchase(t, refId, chatId, 5000, 5500)
+				testUpdate(t, refId, types.DigiflazzTrxStatusSuccess, 5000)
+			},
+			want: map[string]int64{
+				"wallet": -4500, LedgerCash: 10000, LedgerRevenue: -5500,
+				LedgerCost: 5000, LedgerDeposit: -5000,
+			},
+		},
+		{
+			name: "success reported twice",
+			run: func(t *testing.T, chatId int64) {
+				refId := fmt.Sprintf("twice-%d", chatId)
+				testTopup(t, chatId, 10000)
+				testPurchase(t, refId, chatId, 5000, 5500)
+				testUpdate(t, refId, types.DigiflazzTrxStatusSuccess, 5000)
+				testUpdate(t, refId, types.DigiflazzTrxStatusSuccess, 5000)
+			},
+			want: map[string]int64{
+				"wallet": -4500, LedgerCash: 10000, LedgerRevenue: -5500,
+				LedgerCost: 5000, LedgerDeposit: -5000,
+			},
+		},
+		{
+			name: "success at another price",
+			run: func(t *testing.T, chatId int64) {
+				refId := fmt.Sprintf("price-%d", chatId)
+				testTopup(t, chatId, 10000)
+				testPurchase(t, refId, chatId, 5000, 5500)
+				testUpdate(t, refId, types.DigiflazzTrxStatusSuccess, 5100)
+			},
+			want: map[string]int64{
+				"wallet": -4500, LedgerCash: 10000, LedgerRevenue: -5500,
+				LedgerCost: 5100, LedgerDeposit: -5100,
+			},
+		},
+		{
+			name: "failed after success",
+			run: func(t *testing.T, chatId int64) {
+				refId := fmt.Sprintf("reversed-%d", chatId)
+				testTopup(t, chatId, 10000)
+				testPurchase(t, refId, chatId, 5000, 5500)
+				testUpdate(t, refId, types.DigiflazzTrxStatusSuccess, 5000)
+				testUpdate(t, refId, types.DigiflazzTrxStatusFailed, 5000)
+				testUpdate(t, refId, types.DigiflazzTrxStatusFailed, 5000)
+			},
+			want: map[string]int64{"wallet": -4500, LedgerCash: 10000, LedgerRevenue: -5500},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chatId := newTestChat()
+			before := ledgerBalances(t)
+			tt.run(t, chatId)
+			after := ledgerBalances(t)
+
+			for _, code := range []string{LedgerWalletAccount(chatId), LedgerCash, LedgerRevenue, LedgerCost, LedgerDeposit, LedgerEquityAccount} {
+				key := code
+				if code == LedgerWalletAccount(chatId) {
+					key = "wallet"
+				}
+				if got := after[code] - before[code]; got != tt.want[key] {
+					t.Errorf("%s changed by %d, want %d", code, got, tt.want[key])
+				}
+			}
+
+			balance, err := database.Sqlc.GetWalletBalance(context.Background(), chatId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if balance != -after[LedgerWalletAccount(chatId)] {
+				t.Errorf("wallet balance %d, ledger account %d", balance, after[LedgerWalletAccount(chatId)])
+			}
+
+			report, err := VerifyLedger(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := report.Inconsistencies(); n != 0 {
+				t.Errorf("VerifyLedger found %d inconsistencies", n)
+			}
+		})
+	}
+}
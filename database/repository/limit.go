@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+)
+
+// UserLimits holds the effective limits of a user. Zero means unlimited.
+type UserLimits struct {
+	MaxTrxPrice   int64
+	MaxDailyTotal int64
+	MaxDailyCount int64
+	// IsDefault is true when the limits come from the config instead of the database
+	IsDefault bool
+}
+
+func GetUserLimits(ctx context.Context, chatId int64) (*UserLimits, error) {
+	limit, err := database.Sqlc.GetUserLimit(ctx, chatId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &UserLimits{
+				MaxTrxPrice:   config.Cfg.TrxLimitMaxPrice,
+				MaxDailyTotal: config.Cfg.TrxLimitMaxDailyTotal,
+				MaxDailyCount: config.Cfg.TrxLimitMaxDailyCount,
+				IsDefault:     true,
+			}, nil
+		}
+		return nil, err
+	}
+
+	return &UserLimits{
+		MaxTrxPrice:   limit.MaxTrxPrice,
+		MaxDailyTotal: limit.MaxDailyTotal,
+		MaxDailyCount: limit.MaxDailyCount,
+	}, nil
+}
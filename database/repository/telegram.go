@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/fidrasofyan/digiflazz-bot/database"
 )
@@ -14,68 +16,69 @@ func TelegramGetChat(ctx context.Context, id int64) (*database.Chat, error) {
 type TelegramSetChatParams struct {
 	ID      int64
 	Command string
-	Step    int64
+	State   string
 	Data    []byte
+	Stack   []byte
+	// Timeout ends the conversation when the chat is idle for longer. Zero means never.
+	Timeout time.Duration
 }
 
+// TelegramSetChat saves the conversation of a chat, replacing the previous one.
 func TelegramSetChat(ctx context.Context, arg *TelegramSetChatParams) (*database.Chat, error) {
-	chatExists, err := database.Sqlc.IsChatExists(ctx, arg.ID)
-	if err != nil {
-		return nil, err
+	now := time.Now()
+	var expiresAt sql.NullTime
+	if arg.Timeout > 0 {
+		expiresAt = sql.NullTime{Time: now.Add(arg.Timeout), Valid: true}
 	}
 
-	if chatExists != 0 {
-		chat, err := database.Sqlc.UpdateChat(ctx, &database.UpdateChatParams{
-			Command: arg.Command,
-			Step:    arg.Step,
-			Data:    arg.Data,
-			ID:      arg.ID,
-		})
-		return chat, err
-	}
+	return database.Sqlc.UpsertChat(ctx, &database.UpsertChatParams{
+		ID:        arg.ID,
+		Command:   arg.Command,
+		State:     arg.State,
+		Data:      arg.Data,
+		Stack:     arg.Stack,
+		UpdatedAt: sql.NullTime{Time: now, Valid: true},
+		ExpiresAt: expiresAt,
+	})
+}
+
+func TelegramDeleteChat(ctx context.Context, id int64) error {
+	return database.Sqlc.DeleteChat(ctx, id)
+}
 
-	chat, err := database.Sqlc.CreateChat(ctx, &database.CreateChatParams{
-		ID:      arg.ID,
-		Command: arg.Command,
-		Step:    arg.Step,
-		Data:    arg.Data,
+// TelegramEndChat deletes the chat only if it is still in the given command,
+// so it doesn't end a conversation that was handed over to another command.
+func TelegramEndChat(ctx context.Context, id int64, command string) error {
+	return database.Sqlc.DeleteChatByCommand(ctx, &database.DeleteChatByCommandParams{
+		ID:      id,
+		Command: command,
 	})
-	return chat, err
 }
 
-type TelegramSetReplyMarkupParams struct {
-	ID          int64
-	Step        int64
-	ReplyMarkup []byte
+// TelegramClaimChat atomically deletes the chat if it is still at the given
+// command and state. It returns sql.ErrNoRows if another request claimed it first.
+func TelegramClaimChat(ctx context.Context, id int64, command string, state string) (*database.Chat, error) {
+	return database.Sqlc.ClaimChat(ctx, &database.ClaimChatParams{
+		ID:      id,
+		Command: command,
+		State:   state,
+	})
 }
 
-func TelegramSetReplyMarkup(ctx context.Context, params *TelegramSetReplyMarkupParams) error {
-	switch params.Step {
-	case 1:
-		return database.Sqlc.UpdateReplyMarkup1(ctx, &database.UpdateReplyMarkup1Params{
-			ID:           params.ID,
-			ReplyMarkup1: params.ReplyMarkup,
-		})
-	case 2:
-		return database.Sqlc.UpdateReplyMarkup2(ctx, &database.UpdateReplyMarkup2Params{
-			ID:           params.ID,
-			ReplyMarkup2: params.ReplyMarkup,
-		})
-	case 3:
-		return database.Sqlc.UpdateReplyMarkup3(ctx, &database.UpdateReplyMarkup3Params{
-			ID:           params.ID,
-			ReplyMarkup3: params.ReplyMarkup,
-		})
-	case 4:
-		return database.Sqlc.UpdateReplyMarkup4(ctx, &database.UpdateReplyMarkup4Params{
-			ID:           params.ID,
-			ReplyMarkup4: params.ReplyMarkup,
-		})
-	default:
-		return nil
+// TelegramMarkUpdateProcessed records the update ID and reports whether
+// it is seen for the first time.
+func TelegramMarkUpdateProcessed(ctx context.Context, updateId int64) (bool, error) {
+	rows, err := database.Sqlc.InsertTelegramUpdate(ctx, &database.InsertTelegramUpdateParams{
+		ID:        updateId,
+		CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return false, err
 	}
+	return rows > 0, nil
 }
 
-func TelegramDeleteChat(ctx context.Context, id int64) error {
-	return database.Sqlc.DeleteChat(ctx, id)
+// TelegramUnmarkUpdateProcessed forgets the update ID, so a redelivery is handled again.
+func TelegramUnmarkUpdateProcessed(ctx context.Context, updateId int64) error {
+	return database.Sqlc.DeleteTelegramUpdate(ctx, updateId)
 }
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+)
+
+type UpdateTransactionParams struct {
+	RefID   string
+	Price   int64
+	Status  string
+	RC      string
+	SN      *string
+	Message string
+}
+
+// UpdateTransaction stores a transaction status. The fields of a PLN token SN
+// are stored too, and the price Digiflazz charged is journaled once it succeeds.
+func UpdateTransaction(ctx context.Context, arg *UpdateTransactionParams) error {
+	tx, err := database.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := database.Sqlc.WithTx(tx)
+	now := time.Now()
+
+	err = qtx.UpdateTransactionByRefID(ctx, &database.UpdateTransactionByRefIDParams{
+		Price:     arg.Price,
+		Status:    arg.Status,
+		Rc:        &arg.RC,
+		Sn:        arg.SN,
+		Message:   &arg.Message,
+		UpdatedAt: sql.NullTime{Time: now, Valid: true},
+		RefID:     arg.RefID,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch arg.Status {
+	case string(types.DigiflazzTrxStatusSuccess):
+		err = postLedgerCost(ctx, qtx, arg.RefID, arg.Price)
+	case string(types.DigiflazzTrxStatusFailed):
+		err = reverseLedgerCost(ctx, qtx, arg.RefID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if arg.SN != nil {
+		if token, ok := types.ParseDigiflazzPLNToken(*arg.SN); ok {
+			err = qtx.UpsertPLNToken(ctx, &database.UpsertPLNTokenParams{
+				RefID:        arg.RefID,
+				Token:        token.Token,
+				CustomerName: nullableString(token.CustomerName),
+				Tariff:       nullableString(token.Tariff),
+				Power:        nullableString(token.Power),
+				Kwh:          nullableString(token.KWh),
+				CreatedAt:    sql.NullTime{Time: now, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+type DailyTransactionSummary struct {
+	Total int64
+	Count int64
+}
+
+// GetDailyTransactionSummary sums today's non-failed transactions of a chat.
+func GetDailyTransactionSummary(ctx context.Context, chatId int64) (*DailyTransactionSummary, error) {
+	// The day starts at midnight in the app's timezone, not the server's
+	now := time.Now().In(config.Cfg.AppLocation)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	// Times are stored in the local zone and compared as text
+	summary, err := database.Sqlc.GetDailyTransactionSummary(ctx, &database.GetDailyTransactionSummaryParams{
+		ChatID:    chatId,
+		CreatedAt: sql.NullTime{Time: startOfDay.Local(), Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DailyTransactionSummary{
+		Total: summary.Total,
+		Count: summary.Count,
+	}, nil
+}
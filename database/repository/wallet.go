@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+)
+
+// Kinds of wallet entries
+const (
+	WalletEntryTopup    = "topup"
+	WalletEntryPurchase = "purchase"
+	WalletEntryRefund   = "refund"
+)
+
+// Statuses of top-up requests
+const (
+	WalletTopupPending  = "pending"
+	WalletTopupApproved = "approved"
+	WalletTopupRejected = "rejected"
+)
+
+// ErrInsufficientBalance is returned when the wallet can't cover a purchase.
+var ErrInsufficientBalance = errors.New("insufficient wallet balance")
+
+// ErrRefundNotFailed is returned when refunding a transaction that isn't
+// stored as failed.
+var ErrRefundNotFailed = errors.New("transaction is not failed")
+
+type CreateWalletPurchaseParams struct {
+	RefID        string
+	ChatID       int64
+	BuyerSkuCode string
+	CustomerNo   string
+	// Price is the Digiflazz price, SellingPrice is debited from the wallet
+	Price        int64
+	SellingPrice int64
+}
+
+// CreateWalletPurchase records a pending transaction, debits the wallet and
+// journals the purchase in the same database transaction, so none exists
+// without the others.
+func CreateWalletPurchase(ctx context.Context, arg *CreateWalletPurchaseParams) error {
+	tx, err := database.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := database.Sqlc.WithTx(tx)
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+
+	_, err = qtx.CreateTransaction(ctx, &database.CreateTransactionParams{
+		RefID:        arg.RefID,
+		ChatID:       arg.ChatID,
+		BuyerSkuCode: arg.BuyerSkuCode,
+		CustomerNo:   arg.CustomerNo,
+		Price:        arg.Price,
+		Status:       string(types.DigiflazzTrxStatusPending),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	})
+	if err != nil {
+		return err
+	}
+
+	debited, err := qtx.DebitWallet(ctx, &database.DebitWalletParams{
+		ChatID:    arg.ChatID,
+		Amount:    arg.SellingPrice,
+		Kind:      WalletEntryPurchase,
+		RefID:     arg.RefID,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+	if debited == 0 {
+		return ErrInsufficientBalance
+	}
+
+	err = postLedgerPurchase(ctx, qtx, arg)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RefundWalletPurchase credits the wallet back for a failed transaction. It
+// returns nil if the transaction wasn't paid from a wallet or was already
+// refunded, and ErrRefundNotFailed unless the stored transaction is failed.
+func RefundWalletPurchase(ctx context.Context, refId string) (*database.WalletEntry, error) {
+	tx, err := database.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := database.Sqlc.WithTx(tx)
+
+	// Only what the stored transaction says counts, not the caller
+	trx, err := qtx.GetTransactionByRefID(ctx, refId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if trx.Status != string(types.DigiflazzTrxStatusFailed) {
+		return nil, ErrRefundNotFailed
+	}
+
+	entry, err := qtx.RefundWalletPurchase(ctx, &database.RefundWalletPurchaseParams{
+		CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		RefID:     refId,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if entry.ChatID != trx.ChatID {
+		return nil, fmt.Errorf("wallet %d paid for transaction %s of chat %d", entry.ChatID, refId, trx.ChatID)
+	}
+
+	err = postLedgerRefund(ctx, qtx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// DecideWalletTopup approves or rejects a pending top-up request. An approved
+// top-up is credited in the same database transaction. It returns
+// sql.ErrNoRows if the request was already decided.
+func DecideWalletTopup(ctx context.Context, id int64, status string, decidedBy int64) (*database.WalletTopup, error) {
+	tx, err := database.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := database.Sqlc.WithTx(tx)
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+
+	topup, err := qtx.DecideWalletTopup(ctx, &database.DecideWalletTopupParams{
+		Status:    status,
+		DecidedBy: &decidedBy,
+		DecidedAt: now,
+		ID:        id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if topup.Status == WalletTopupApproved {
+		_, err = qtx.CreateWalletEntry(ctx, &database.CreateWalletEntryParams{
+			ChatID:    topup.ChatID,
+			Amount:    topup.Amount,
+			Kind:      WalletEntryTopup,
+			RefID:     strconv.FormatInt(topup.ID, 10),
+			CreatedAt: now,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		err = postLedgerTopup(ctx, qtx, topup)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return topup, nil
+}
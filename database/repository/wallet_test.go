@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+)
+
+func TestRefundWalletPurchase(t *testing.T) {
+	tests := []struct {
+		name string
+		// status is stored before refunding, none leaves the purchase pending
+		status types.DigiflazzTrxStatus
+		// refunds is how many times the purchase is refunded
+		refunds int
+		// unknown refunds a transaction that doesn't exist
+		unknown bool
+		wantErr error
+		// wantRefund is the amount of the first refund, 0 if there is none
+		wantRefund int64
+		// wantBalance is the wallet balance after the refunds
+		wantBalance int64
+	}{
+		{
+			name:        "failed",
+			status:      types.DigiflazzTrxStatusFailed,
+			refunds:     1,
+			wantRefund:  5500,
+			wantBalance: 10000,
+		},
+		{
+			name:        "refunded again",
+			status:      types.DigiflazzTrxStatusFailed,
+			refunds:     3,
+			wantRefund:  5500,
+			wantBalance: 10000,
+		},
+		{
+			name:        "pending",
+			refunds:     1,
+			wantErr:     ErrRefundNotFailed,
+			wantBalance: 4500,
+		},
+		{
+			name:        "successful",
+			status:      types.DigiflazzTrxStatusSuccess,
+			refunds:     1,
+			wantErr:     ErrRefundNotFailed,
+			wantBalance: 4500,
+		},
+		{
+			name:        "unknown transaction",
+			refunds:     1,
+			unknown:     true,
+			wantBalance: 4500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			chatId := newTestChat()
+			refId := fmt.Sprintf("refund-%d", chatId)
+			testTopup(t, chatId, 10000)
+			testPurchase(t, refId, chatId, 5000, 5500)
+			if tt.status != "" {
+				testUpdate(t, refId, tt.status, 5000)
+			}
+			if tt.unknown {
+				refId += "-unknown"
+			}
+
+			for i := range tt.refunds {
+				refund, err := RefundWalletPurchase(ctx, refId)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("refund %d: err = %v, want %v", i, err, tt.wantErr)
+				}
+				var amount int64
+				if refund != nil {
+					amount = refund.Amount
+				}
+				if i == 0 && amount != tt.wantRefund {
+					t.Errorf("refund %d: amount = %d, want %d", i, amount, tt.wantRefund)
+				}
+				if i > 0 && refund != nil {
+					t.Errorf("refund %d: refunded %d again", i, amount)
+				}
+			}
+
+			balance, err := database.Sqlc.GetWalletBalance(ctx, chatId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if balance != tt.wantBalance {
+				t.Errorf("wallet balance = %d, want %d", balance, tt.wantBalance)
+			}
+
+			balances := ledgerBalances(t)
+			if got := -balances[LedgerWalletAccount(chatId)]; got != tt.wantBalance {
+				t.Errorf("wallet ledger account = %d, want %d", got, tt.wantBalance)
+			}
+			report, err := VerifyLedger(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := report.Inconsistencies(); n != 0 {
+				t.Errorf("VerifyLedger found %d inconsistencies", n)
+			}
+		})
+	}
+}
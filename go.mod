@@ -3,10 +3,12 @@ module github.com/fidrasofyan/digiflazz-bot
 go 1.25.3
 
 require (
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/gofiber/fiber/v2 v2.52.9
 	github.com/google/uuid v1.6.0
 	github.com/joho/godotenv v1.5.1
 	github.com/pressly/goose/v3 v3.26.0
+	golang.org/x/image v0.32.0
 	golang.org/x/text v0.30.0
 	modernc.org/sqlite v1.39.1
 )
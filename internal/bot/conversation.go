@@ -0,0 +1,316 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database/repository"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/service"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+// SessionExpiredText tells the user their conversation ended because they were idle.
+const SessionExpiredText = "<i>Sesi sebelumnya berakhir karena tidak ada aktivitas</i>"
+
+// Input is the kind of update a state accepts.
+type Input int
+
+const (
+	InputAny Input = iota
+	InputText
+	InputCallback
+)
+
+// State is a named step of a conversation.
+type State[T any] struct {
+	// Enter shows the state, e.g. a question with buttons. It is called when
+	// the conversation moves into the state with Push or Back.
+	Enter func(ctx context.Context, c *Conv[T]) (*types.TelegramResponse, error)
+	// Input rejects other kinds of updates and ends the conversation.
+	Input Input
+	// Validate checks the answer before Handle. A non-empty message is sent
+	// back and the state is kept, so the user can try again.
+	Validate func(c *Conv[T]) string
+	// Handle processes the answer and moves the conversation on.
+	Handle func(ctx context.Context, c *Conv[T]) (*types.TelegramResponse, error)
+	// Cancel replaces the default reply when the "cancel" button is tapped.
+	Cancel func(ctx context.Context, c *Conv[T]) (*types.TelegramResponse, error)
+	// Timeout overrides the conversation timeout while waiting in this state.
+	Timeout time.Duration
+}
+
+// Conversation is a declarative multi-step command. Its state, data of type T
+// and navigation stack are kept in the chats table between updates.
+//
+// Callbacks "back" and "cancel" are handled for every state: back returns to
+// the previous pushed state, cancel ends the conversation.
+type Conversation[T any] struct {
+	// Command is the command name the router uses to continue the conversation
+	Command string
+	// Initial is entered when a new conversation starts
+	Initial string
+	States  map[string]*State[T]
+	// Timeout ends an idle conversation. Defaults to CONVERSATION_IDLE_MINUTES.
+	Timeout time.Duration
+}
+
+// Conv is the conversation of a chat while an update is handled.
+type Conv[T any] struct {
+	Req    *types.TelegramUpdate
+	ChatId int64
+	Data   T
+
+	conversation *Conversation[T]
+	state        string
+	stack        []string
+	ended        bool
+}
+
+// State returns the current state name.
+func (c *Conv[T]) State() string {
+	return c.state
+}
+
+// Text returns the text of the message, or an empty string for callback queries.
+func (c *Conv[T]) Text() string {
+	if c.Req.Message == nil {
+		return ""
+	}
+	return c.Req.Message.Text
+}
+
+// CallbackData returns the data of the callback query, or an empty string for messages.
+func (c *Conv[T]) CallbackData() string {
+	if c.Req.CallbackQuery == nil {
+		return ""
+	}
+	return c.Req.CallbackQuery.Data
+}
+
+// Push moves to a state, remembering the current one for "back", and enters it.
+func (c *Conv[T]) Push(ctx context.Context, name string) (*types.TelegramResponse, error) {
+	c.stack = append(c.stack, c.state)
+	c.state = name
+	return c.enter(ctx)
+}
+
+// Goto moves to a state without entering it, for handlers that reply themselves.
+// The current state is not remembered.
+func (c *Conv[T]) Goto(name string) {
+	c.state = name
+}
+
+// Back returns to the previous pushed state and enters it.
+// Without one, the conversation is cancelled.
+func (c *Conv[T]) Back(ctx context.Context) (*types.TelegramResponse, error) {
+	if len(c.stack) == 0 {
+		c.End()
+		return c.Reply("<i>Dibatalkan</i>", types.DefaultReplyMarkup), nil
+	}
+	c.state = c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+	return c.enter(ctx)
+}
+
+// End ends the conversation once the update is handled.
+func (c *Conv[T]) End() {
+	c.ended = true
+}
+
+// Claim ends the conversation immediately if it is still in the current state.
+// It returns false if another update claimed it first, e.g. a double tap.
+func (c *Conv[T]) Claim(ctx context.Context) (bool, error) {
+	_, err := repository.TelegramClaimChat(ctx, c.ChatId, c.conversation.Command, c.state)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	c.ended = true
+	return true, nil
+}
+
+// Reply edits the message of the callback query, or sends a new message.
+// The reply markup of an edited message must be an inline keyboard.
+func (c *Conv[T]) Reply(text string, replyMarkup any) *types.TelegramResponse {
+	if c.Req.CallbackQuery != nil {
+		if _, ok := replyMarkup.(types.TelegramInlineKeyboardMarkup); !ok {
+			replyMarkup = nil
+		}
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodEditMessageText,
+			MessageId:   c.Req.CallbackQuery.Message.MessageId,
+			ChatId:      c.Req.CallbackQuery.Message.Chat.Id,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: replyMarkup,
+		}
+	}
+
+	return &types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      c.ChatId,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        text,
+		ReplyMarkup: replyMarkup,
+	}
+}
+
+func (c *Conv[T]) enter(ctx context.Context) (*types.TelegramResponse, error) {
+	state, ok := c.conversation.States[c.state]
+	if !ok || state.Enter == nil {
+		return nil, fmt.Errorf("conversation %s: state %s can't be entered", c.conversation.Command, c.state)
+	}
+	return state.Enter(ctx, c)
+}
+
+// save keeps the conversation for the next update, or deletes it when it ended.
+func (c *Conv[T]) save(ctx context.Context) error {
+	if c.ended {
+		return repository.TelegramEndChat(ctx, c.ChatId, c.conversation.Command)
+	}
+
+	data, err := json.Marshal(c.Data)
+	if err != nil {
+		return err
+	}
+	stack, err := json.Marshal(c.stack)
+	if err != nil {
+		return err
+	}
+
+	timeout := config.Cfg.ConversationIdleTimeout
+	if c.conversation.Timeout > 0 {
+		timeout = c.conversation.Timeout
+	}
+	if state, ok := c.conversation.States[c.state]; ok && state.Timeout > 0 {
+		timeout = state.Timeout
+	}
+
+	_, err = repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
+		ID:      c.ChatId,
+		Command: c.conversation.Command,
+		State:   c.state,
+		Data:    data,
+		Stack:   stack,
+		Timeout: timeout,
+	})
+	return err
+}
+
+// Handle starts or continues the conversation of the chat. It is the command handler.
+func (cv *Conversation[T]) Handle(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
+	var chatId int64
+
+	// Is it callback query?
+	if req.CallbackQuery != nil {
+		chatId = req.CallbackQuery.From.Id
+	} else {
+		chatId = req.Message.Chat.Id
+	}
+
+	// Get chat
+	chat, err := repository.TelegramGetChat(ctx, chatId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, util.NewError(err)
+	}
+
+	c := &Conv[T]{
+		Req:          req,
+		ChatId:       chatId,
+		conversation: cv,
+	}
+
+	// New conversation
+	if chat.ID == 0 || chat.Command != cv.Command {
+		c.state = cv.Initial
+		return c.finish(ctx)(c.enter(ctx))
+	}
+
+	c.state = chat.State
+	if len(chat.Data) > 0 {
+		if err := json.Unmarshal(chat.Data, &c.Data); err != nil {
+			return nil, util.NewError(err)
+		}
+	}
+	if len(chat.Stack) > 0 {
+		if err := json.Unmarshal(chat.Stack, &c.stack); err != nil {
+			return nil, util.NewError(err)
+		}
+	}
+
+	if req.CallbackQuery != nil {
+		// Answer callback query
+		go func() {
+			acqCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			service.Telegram.AnswerCallbackQuery(acqCtx, &service.TelegramAnswerCallbackQueryParams{
+				CallbackQueryId: req.CallbackQuery.Id,
+			})
+		}()
+	}
+
+	state, ok := cv.States[c.state]
+	if !ok || state.Handle == nil {
+		c.End()
+		return c.finish(ctx)(c.Reply("<i>Unhandled step</i>", types.DefaultReplyMarkup), nil)
+	}
+
+	if (state.Input == InputText && req.Message == nil) || (state.Input == InputCallback && req.CallbackQuery == nil) {
+		c.End()
+		return c.finish(ctx)(c.Reply("<i>Perintah tidak valid</i>", types.DefaultReplyMarkup), nil)
+	}
+
+	switch c.CallbackData() {
+	case "cancel":
+		if state.Cancel != nil {
+			c.End()
+			return c.finish(ctx)(state.Cancel(ctx, c))
+		}
+		c.End()
+		return c.finish(ctx)(c.Reply("<i>Dibatalkan</i>", types.DefaultReplyMarkup), nil)
+	case "back":
+		return c.finish(ctx)(c.Back(ctx))
+	}
+
+	if state.Validate != nil {
+		if text := state.Validate(c); text != "" {
+			return c.finish(ctx)(c.Reply(text, nil), nil)
+		}
+	}
+
+	return c.finish(ctx)(state.Handle(ctx, c))
+}
+
+// Start begins the conversation at a state with the given data, e.g. when
+// another command hands the chat over. It replaces the current conversation.
+func (cv *Conversation[T]) Start(ctx context.Context, req *types.TelegramUpdate, chatId int64, state string, data T) (*types.TelegramResponse, error) {
+	c := &Conv[T]{
+		Req:          req,
+		ChatId:       chatId,
+		Data:         data,
+		conversation: cv,
+		state:        state,
+	}
+	return c.finish(ctx)(c.enter(ctx))
+}
+
+// finish saves the conversation after the update is handled successfully.
+func (c *Conv[T]) finish(ctx context.Context) func(*types.TelegramResponse, error) (*types.TelegramResponse, error) {
+	return func(resp *types.TelegramResponse, err error) (*types.TelegramResponse, error) {
+		if err != nil {
+			return nil, err
+		}
+		if err := c.save(ctx); err != nil {
+			return nil, util.NewError(err)
+		}
+		return resp, nil
+	}
+}
@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"context"
+	"sync"
+)
+
+// Dispatcher runs the jobs of each chat in order, in its own goroutine, so a
+// slow job of one chat doesn't hold up the others.
+type Dispatcher struct {
+	mu     sync.Mutex
+	queues map[int64][]func(context.Context)
+	sem    chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher returns a dispatcher that runs at most workers jobs at the same time.
+func NewDispatcher(workers int) *Dispatcher {
+	return &Dispatcher{
+		queues: make(map[int64][]func(context.Context)),
+		sem:    make(chan struct{}, workers),
+	}
+}
+
+// Dispatch queues the job behind the pending jobs of the chat. Jobs that
+// haven't started when the context is canceled are dropped.
+func (d *Dispatcher) Dispatch(ctx context.Context, chatId int64, job func(context.Context)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	queue, running := d.queues[chatId]
+	d.queues[chatId] = append(queue, job)
+	if !running {
+		d.wg.Add(1)
+		go d.work(ctx, chatId)
+	}
+}
+
+// Wait blocks until every queue is empty.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// work runs the queued jobs of a chat until the queue is empty.
+func (d *Dispatcher) work(ctx context.Context, chatId int64) {
+	defer d.wg.Done()
+	for {
+		d.mu.Lock()
+		job := d.queues[chatId][0]
+		d.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			d.mu.Lock()
+			delete(d.queues, chatId)
+			d.mu.Unlock()
+			return
+		case d.sem <- struct{}{}:
+		}
+		job(ctx)
+		<-d.sem
+
+		d.mu.Lock()
+		queue := d.queues[chatId][1:]
+		if len(queue) == 0 {
+			delete(d.queues, chatId)
+			d.mu.Unlock()
+			return
+		}
+		d.queues[chatId] = queue
+		d.mu.Unlock()
+	}
+}
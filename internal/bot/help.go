@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/channel"
+	"github.com/fidrasofyan/digiflazz-bot/internal/service"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+// Telegram only accepts lowercase letters, digits and underscores in the command menu
+var menuCommandRegex = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// Help lists the commands available to the chat's role.
+func (r *Router) Help(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.Message.Chat.Id
+	role, err := RoleOf(ctx, chatId)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+
+	var textB strings.Builder
+	for _, section := range roles {
+		if section > role {
+			break
+		}
+
+		var sectionB strings.Builder
+		for _, cmd := range r.Commands(section) {
+			if cmd.Role != section || cmd.Description == "" {
+				continue
+			}
+			sectionB.WriteString(cmd.Description)
+			sectionB.WriteString("\n")
+			for _, usage := range cmd.usage() {
+				sectionB.WriteString("<code>" + html.EscapeString(usage) + "</code>\n")
+			}
+		}
+		if sectionB.Len() == 0 {
+			continue
+		}
+
+		if section == RoleAdmin {
+			textB.WriteString("\n<b>Admin</b>\n")
+		}
+		textB.WriteString(sectionB.String())
+	}
+
+	return &types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      chatId,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        strings.TrimSpace(textB.String()),
+		ReplyMarkup: types.DefaultReplyMarkup,
+	}, nil
+}
+
+// BotCommands returns the command menu for a role. Only commands with a name
+// or alias Telegram accepts are included, e.g. "cek saldo" is listed as /saldo.
+func (r *Router) BotCommands(role Role) []service.TelegramCommand {
+	var commands []service.TelegramCommand
+	for _, cmd := range r.Commands(role) {
+		if cmd.Description == "" {
+			continue
+		}
+		for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+			if menuCommandRegex.MatchString(name) && !strings.HasPrefix(name, "_") {
+				commands = append(commands, service.TelegramCommand{
+					Command:     "/" + name,
+					Description: cmd.Description,
+				})
+				break
+			}
+		}
+	}
+	return commands
+}
+
+func (c *Command) usage() []string {
+	if len(c.Usage) > 0 {
+		return c.Usage
+	}
+	return []string{c.Name}
+}
+
+// SyncCommands sets the command menu of a chat to the commands its role allows.
+func (r *Router) SyncCommands(ctx context.Context, chatId int64) error {
+	// Only Telegram has a command menu
+	if channel.IsWhatsApp(chatId) {
+		return nil
+	}
+
+	role, err := RoleOf(ctx, chatId)
+	if err != nil {
+		return err
+	}
+
+	return service.Telegram.SetMyCommands(ctx, &service.TelegramSetMyCommandsParams{
+		Commands: r.BotCommands(role),
+		Scope: &service.TelegramBotCommandScope{
+			Type:   "chat",
+			ChatId: chatId,
+		},
+	})
+}
+
+// SyncAllCommands sets the guest menu for every private chat, then the menu of
+// each chat known from the config or set by an admin.
+func (r *Router) SyncAllCommands(ctx context.Context) error {
+	err := service.Telegram.SetMyCommands(ctx, &service.TelegramSetMyCommandsParams{
+		Commands: r.BotCommands(RoleGuest),
+		Scope: &service.TelegramBotCommandScope{
+			Type: "all_private_chats",
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	chatRoles, err := ChatRoles(ctx)
+	if err != nil {
+		return err
+	}
+	for chatId, role := range chatRoles {
+		if channel.IsWhatsApp(chatId) {
+			continue
+		}
+		err := service.Telegram.SetMyCommands(ctx, &service.TelegramSetMyCommandsParams{
+			Commands: r.BotCommands(role),
+			Scope: &service.TelegramBotCommandScope{
+				Type:   "chat",
+				ChatId: chatId,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
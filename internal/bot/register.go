@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+// Register makes a guest a customer when CUSTOMER_REGISTRATION is open.
+// Otherwise an admin has to set the role.
+func (r *Router) Register(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.Message.Chat.Id
+	role, err := RoleOf(ctx, chatId)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+
+	var text string
+	switch {
+	case role >= RoleCustomer:
+		text = "<i>Anda sudah terdaftar</i>"
+	case config.Cfg.CustomerRegistration != "open":
+		text = fmt.Sprintf("<i>Pendaftaran ditutup. Hubungi admin dengan chat ID</i> <code>%d</code>", chatId)
+	default:
+		err = SetRole(ctx, chatId, RoleCustomer, chatId)
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+
+		err = r.SyncCommands(ctx, chatId)
+		if err != nil {
+			log.Printf("Error syncing commands of %d: %v", chatId, err)
+		}
+		text = "Pendaftaran berhasil. Isi saldo dompet dengan <code>topup nominal</code>, lalu mulai belanja."
+	}
+
+	return &types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      chatId,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        text,
+		ReplyMarkup: types.DefaultReplyMarkup,
+	}, nil
+}
@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+)
+
+// Role is the access level of a chat. Higher roles include the lower ones.
+type Role int
+
+const (
+	RoleGuest    Role = iota // anyone who talks to the bot
+	RoleCustomer             // end customers, who pay from their wallet
+	RoleUser                 // TELEGRAM_ALLOWED_IDS
+	RoleAdmin                // TELEGRAM_ADMIN_IDS
+)
+
+var roles = []Role{RoleGuest, RoleCustomer, RoleUser, RoleAdmin}
+
+func (r Role) String() string {
+	switch r {
+	case RoleCustomer:
+		return "customer"
+	case RoleUser:
+		return "user"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "guest"
+	}
+}
+
+// ParseRole parses the name of a role.
+func ParseRole(name string) (Role, bool) {
+	for _, role := range roles {
+		if role.String() == name {
+			return role, true
+		}
+	}
+	return RoleGuest, false
+}
+
+// configRole returns the role from TELEGRAM_ALLOWED_IDS and TELEGRAM_ADMIN_IDS.
+func configRole(chatId int64) Role {
+	if slices.Contains(config.Cfg.TelegramAdminIds, chatId) {
+		return RoleAdmin
+	}
+	if slices.Contains(config.Cfg.TelegramAllowedIds, chatId) {
+		return RoleUser
+	}
+	return RoleGuest
+}
+
+// RoleOf returns the role of a chat. A role set by an admin overrides the config.
+func RoleOf(ctx context.Context, chatId int64) (Role, error) {
+	userRole, err := database.Sqlc.GetUserRole(ctx, chatId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return configRole(chatId), nil
+		}
+		return RoleGuest, err
+	}
+	role, _ := ParseRole(userRole.Role)
+	return role, nil
+}
+
+// SetRole overrides the role of a chat.
+func SetRole(ctx context.Context, chatId int64, role Role, updatedBy int64) error {
+	return database.Sqlc.UpsertUserRole(ctx, &database.UpsertUserRoleParams{
+		ID:        chatId,
+		Role:      role.String(),
+		UpdatedBy: &updatedBy,
+		UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+}
+
+// ResetRole removes the override, so the role comes from the config again.
+func ResetRole(ctx context.Context, chatId int64) error {
+	return database.Sqlc.DeleteUserRole(ctx, chatId)
+}
+
+// ChatRoles returns the role of every chat known from the config or set by an admin.
+func ChatRoles(ctx context.Context) (map[int64]Role, error) {
+	chatRoles := make(map[int64]Role)
+	for _, chatId := range config.Cfg.TelegramAllowedIds {
+		chatRoles[chatId] = configRole(chatId)
+	}
+
+	userRoles, err := database.Sqlc.ListUserRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, userRole := range userRoles {
+		chatRoles[userRole.ID], _ = ParseRole(userRole.Role)
+	}
+
+	return chatRoles, nil
+}
+
+// ChatsWithRole returns the chats that have at least the given role, sorted by id.
+func ChatsWithRole(ctx context.Context, role Role) ([]int64, error) {
+	chatRoles, err := ChatRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	chatIds := []int64{}
+	for chatId, chatRole := range chatRoles {
+		if chatRole >= role {
+			chatIds = append(chatIds, chatId)
+		}
+	}
+	slices.Sort(chatIds)
+	return chatIds, nil
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+// ManageRole shows or changes the role of a chat (admin only). The chat's
+// command menu is updated right away.
+//
+//	role <chat_id>
+//	role <chat_id> guest|customer|user|admin
+//	role <chat_id> default
+func (r *Router) ManageRole(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
+	adminId := req.Message.Chat.Id
+	args := strings.Fields(strings.ToLower(req.Message.Text))[1:]
+	chatId, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+
+	if len(args) == 2 {
+		// Prevent admins from locking themselves out
+		if chatId == adminId {
+			return &types.TelegramResponse{
+				Method:      types.TelegramMethodSendMessage,
+				ChatId:      adminId,
+				ParseMode:   types.TelegramParseModeHTML,
+				Text:        "<i>Tidak bisa mengubah role sendiri</i>",
+				ReplyMarkup: types.DefaultReplyMarkup,
+			}, nil
+		}
+
+		if args[1] == "default" {
+			err = ResetRole(ctx, chatId)
+		} else {
+			role, ok := ParseRole(args[1])
+			if !ok {
+				return nil, util.NewError(fmt.Errorf("invalid role: %s", args[1]))
+			}
+			err = SetRole(ctx, chatId, role, adminId)
+		}
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+
+		// Keep the command menu in sync. The chat may not have started the bot yet.
+		err = r.SyncCommands(ctx, chatId)
+		if err != nil {
+			log.Printf("Error syncing commands of %d: %v", chatId, err)
+		}
+	}
+
+	role, err := RoleOf(ctx, chatId)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+
+	return &types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      adminId,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        fmt.Sprintf("Role <code>%d</code>: <b>%s</b>", chatId, role),
+		ReplyMarkup: types.DefaultReplyMarkup,
+	}, nil
+}
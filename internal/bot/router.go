@@ -0,0 +1,339 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database/repository"
+	"github.com/fidrasofyan/digiflazz-bot/internal/channel"
+	"github.com/fidrasofyan/digiflazz-bot/internal/service"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+// HandlerFunc handles an update. A nil response means there is nothing to reply.
+type HandlerFunc func(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error)
+
+// Command is a registered bot command.
+type Command struct {
+	// Name is the normalized text that triggers the command, e.g. "cek saldo".
+	// Names starting with "_" are internal conversations and can't be typed.
+	// Commands that only match a pattern may leave it empty.
+	Name    string
+	Aliases []string
+	// Pattern matches free-form commands such as "IG100 085808580858".
+	// It is tried against the lowercased text when no name matches.
+	Pattern *regexp.Regexp
+	// Usage lists the examples shown in /help. Defaults to the name.
+	Usage       []string
+	Description string
+	Role        Role
+	// Expensive commands call Digiflazz or do heavy work. The rate limiter
+	// gives them a stricter allowance.
+	Expensive bool
+	Handler   HandlerFunc
+}
+
+type callbackRoute struct {
+	prefix  string
+	role    Role
+	handler HandlerFunc
+}
+
+// Router dispatches Telegram updates to the registered commands.
+// It doesn't depend on the transport, so the webhook and long polling share it.
+type Router struct {
+	commands  []*Command
+	names     map[string]*Command
+	callbacks []*callbackRoute
+	notFound  HandlerFunc
+}
+
+func NewRouter() *Router {
+	return &Router{
+		names: make(map[string]*Command),
+	}
+}
+
+// Handle registers a command. It panics on duplicate names, like http.ServeMux.
+func (r *Router) Handle(cmd *Command) {
+	for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+		if name == "" {
+			continue
+		}
+		if _, ok := r.names[name]; ok {
+			panic("bot: duplicate command " + name)
+		}
+		r.names[name] = cmd
+	}
+	r.commands = append(r.commands, cmd)
+}
+
+// HandleCallback registers a handler for callback queries whose data starts with prefix.
+// Callbacks without a matching prefix belong to the chat's conversation.
+func (r *Router) HandleCallback(prefix string, role Role, handler HandlerFunc) {
+	r.callbacks = append(r.callbacks, &callbackRoute{
+		prefix:  prefix,
+		role:    role,
+		handler: handler,
+	})
+}
+
+// NotFound sets the handler for unknown commands and invalid sessions.
+func (r *Router) NotFound(handler HandlerFunc) {
+	r.notFound = handler
+}
+
+// Commands returns the registered commands available to a role, in registration order.
+func (r *Router) Commands(role Role) []*Command {
+	commands := make([]*Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		if cmd.Role <= role {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands
+}
+
+// Dispatch routes an update to its handler. The update ID is claimed before
+// handling, so a redelivered update is only handled once, and released if the
+// handler fails, so Telegram can retry it.
+func (r *Router) Dispatch(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
+	if req.UpdateId == 0 {
+		return r.dispatch(ctx, req)
+	}
+
+	// Skip updates redelivered by Telegram
+	isNew, err := repository.TelegramMarkUpdateProcessed(ctx, req.UpdateId)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+	if !isNew {
+		return nil, nil
+	}
+
+	resp, err := r.dispatch(ctx, req)
+	if err != nil {
+		// The handler's context may be done already
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if uerr := repository.TelegramUnmarkUpdateProcessed(releaseCtx, req.UpdateId); uerr != nil {
+			log.Printf("Error releasing update %d: %v", req.UpdateId, uerr)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (r *Router) dispatch(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
+	var chatId int64
+	var command string
+
+	// Is it callback query?
+	if req.CallbackQuery != nil {
+		// Set chat id
+		chatId = req.CallbackQuery.From.Id
+
+		for _, route := range r.callbacks {
+			if strings.HasPrefix(req.CallbackQuery.Data, route.prefix) {
+				role, err := RoleOf(ctx, chatId)
+				if err != nil {
+					return nil, util.NewError(err)
+				}
+				if role < route.role {
+					return accessDenied(req), nil
+				}
+				return route.handler(ctx, req)
+			}
+		}
+	} else if req.Message != nil {
+		// Set chat id
+		chatId = req.Message.Chat.Id
+
+		// Only text message is supported
+		if req.Message.Text == "" {
+			return &types.TelegramResponse{
+				Method:      types.TelegramMethodSendMessage,
+				ChatId:      chatId,
+				ParseMode:   types.TelegramParseModeHTML,
+				Text:        "<i>Only text command is supported</i>",
+				ReplyMarkup: types.DefaultReplyMarkup,
+			}, nil
+		}
+
+		command = normalizeCommand(req.Message.Text)
+	} else {
+		return nil, nil
+	}
+
+	// Is it "cancel" command?
+	if command == "cancel" {
+		// Delete chat
+		err := repository.TelegramDeleteChat(ctx, chatId)
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        "<i>Dibatalkan</i>",
+			ReplyMarkup: types.DefaultReplyMarkup,
+		}, nil
+	}
+
+	// Get chat
+	chat, err := repository.TelegramGetChat(ctx, chatId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, util.NewError(err)
+	}
+
+	// The conversation expired before the cleanup job removed it.
+	// Tell the user instead of handing the update to it.
+	if chat.ID != 0 && chat.ExpiresAt.Valid && time.Now().After(chat.ExpiresAt.Time) {
+		err := repository.TelegramDeleteChat(ctx, chatId)
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+		chat.ID = 0
+
+		// Its buttons belong to the expired conversation
+		if req.CallbackQuery != nil {
+			return sessionExpired(req), nil
+		}
+
+		// Handle the message as a new command
+		err = channel.Send(ctx, chatId, &channel.Message{
+			Text: SessionExpiredText,
+			Menu: true,
+		})
+		if err != nil {
+			log.Printf("Error sending message: %v", err)
+		}
+	}
+
+	var cmd *Command
+	if chat.ID != 0 {
+		// Continue the conversation
+		cmd = r.names[chat.Command]
+	} else if !strings.HasPrefix(command, "_") {
+		cmd = r.match(command, req)
+	}
+
+	role, err := RoleOf(ctx, chatId)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+	if cmd == nil {
+		if role < RoleCustomer {
+			return accessDenied(req), nil
+		}
+		if r.notFound == nil {
+			return nil, nil
+		}
+		return r.notFound(ctx, req)
+	}
+	if role < cmd.Role {
+		return accessDenied(req), nil
+	}
+
+	return cmd.Handler(ctx, req)
+}
+
+func (r *Router) match(command string, req *types.TelegramUpdate) *Command {
+	if cmd, ok := r.names[command]; ok {
+		return cmd
+	}
+	if req.Message == nil {
+		return nil
+	}
+
+	return r.matchPattern(req.Message.Text)
+}
+
+// Match returns the command a text message goes to, or nil if there is none.
+func (r *Router) Match(text string) *Command {
+	if cmd, ok := r.names[normalizeCommand(text)]; ok {
+		return cmd
+	}
+	return r.matchPattern(text)
+}
+
+// matchPattern returns the first registered command whose pattern matches the text.
+func (r *Router) matchPattern(text string) *Command {
+	text = strings.ToLower(strings.TrimSpace(text))
+	for _, cmd := range r.commands {
+		if cmd.Pattern != nil && cmd.Pattern.MatchString(text) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// normalizeCommand lowercases the text and removes the leading slash and underscores,
+// so internal commands can't be called directly.
+func normalizeCommand(text string) string {
+	command := strings.TrimSpace(strings.ToLower(text))
+	// Limit command length
+	if len(command) > 30 {
+		command = command[:30]
+	}
+	// Remove leading slashes
+	command = strings.TrimLeft(command, "/")
+	// Remove leading underscores to prevent calling internal commands
+	command = strings.TrimLeft(command, "_")
+	return command
+}
+
+func sessionExpired(req *types.TelegramUpdate) *types.TelegramResponse {
+	// Answer callback query
+	go func() {
+		acqCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		service.Telegram.AnswerCallbackQuery(acqCtx, &service.TelegramAnswerCallbackQueryParams{
+			CallbackQueryId: req.CallbackQuery.Id,
+		})
+	}()
+
+	return &types.TelegramResponse{
+		Method:    types.TelegramMethodEditMessageText,
+		MessageId: req.CallbackQuery.Message.MessageId,
+		ChatId:    req.CallbackQuery.Message.Chat.Id,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      SessionExpiredText,
+	}
+}
+
+func accessDenied(req *types.TelegramUpdate) *types.TelegramResponse {
+	if req.CallbackQuery != nil {
+		// Answer callback query
+		go func() {
+			acqCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			service.Telegram.AnswerCallbackQuery(acqCtx, &service.TelegramAnswerCallbackQueryParams{
+				CallbackQueryId: req.CallbackQuery.Id,
+			})
+		}()
+
+		return &types.TelegramResponse{
+			Method:    types.TelegramMethodEditMessageText,
+			MessageId: req.CallbackQuery.Message.MessageId,
+			ChatId:    req.CallbackQuery.Message.Chat.Id,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      "<i>Access denied</i>",
+		}
+	}
+
+	return &types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      req.Message.Chat.Id,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        "<i>Access denied</i>",
+		ReplyMarkup: types.DefaultReplyMarkup,
+	}
+}
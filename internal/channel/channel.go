@@ -0,0 +1,108 @@
+package channel
+
+import (
+	"context"
+	"html"
+	"reflect"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+)
+
+// Message is a reply that every channel can deliver, each in its own way.
+type Message struct {
+	// Text is HTML as Telegram understands it: <b>, <i>, <code> and <pre>
+	Text string
+	// Buttons come back as callback data, like Telegram's inline keyboard
+	Buttons [][]Button
+	// Replies are suggested answers that come back as text
+	Replies [][]string
+	// Menu shows the main menu, on channels that have one
+	Menu bool
+	// Document is sent with Text as its caption
+	Document *Document
+}
+
+type Button struct {
+	Text string
+	Data string
+}
+
+type Document struct {
+	Name string
+	Data []byte
+	// Image is shown inline instead of as a file
+	Image bool
+}
+
+// Channel delivers messages to the chats of one messaging platform.
+type Channel interface {
+	Send(ctx context.Context, chatId int64, msg *Message) error
+	// Edit replaces the text and buttons of a sent message. Channels that
+	// can't edit do nothing.
+	Edit(ctx context.Context, chatId, messageId int64, msg *Message) error
+	// Delete deletes a message. Channels that can't delete do nothing.
+	Delete(ctx context.Context, chatId, messageId int64) error
+}
+
+// For returns the channel of a chat.
+func For(chatId int64) Channel {
+	if IsWhatsApp(chatId) {
+		return WhatsApp
+	}
+	return Telegram
+}
+
+func Send(ctx context.Context, chatId int64, msg *Message) error {
+	return For(chatId).Send(ctx, chatId, msg)
+}
+
+func Edit(ctx context.Context, chatId, messageId int64, msg *Message) error {
+	return For(chatId).Edit(ctx, chatId, messageId, msg)
+}
+
+func Delete(ctx context.Context, chatId, messageId int64) error {
+	return For(chatId).Delete(ctx, chatId, messageId)
+}
+
+// FromResponse converts the reply of a handler for channels that can't take
+// it as is. It returns nil if there is nothing to send.
+func FromResponse(resp *types.TelegramResponse) *Message {
+	if resp == nil || (resp.Method == types.TelegramMethodAnswerCallbackQuery && resp.Text == "") {
+		return nil
+	}
+
+	msg := &Message{Text: resp.Text}
+	if resp.ParseMode != types.TelegramParseModeHTML {
+		msg.Text = html.EscapeString(resp.Text)
+	}
+
+	switch markup := resp.ReplyMarkup.(type) {
+	case types.TelegramInlineKeyboardMarkup:
+		msg.Buttons = buttonsFromInlineKeyboard(markup.InlineKeyboard)
+	case *types.TelegramInlineKeyboardMarkup:
+		msg.Buttons = buttonsFromInlineKeyboard(markup.InlineKeyboard)
+	case types.TelegramReplyKeyboardMarkup:
+		msg.Replies, msg.Menu = repliesFromKeyboard(&markup)
+	case *types.TelegramReplyKeyboardMarkup:
+		msg.Replies, msg.Menu = repliesFromKeyboard(markup)
+	}
+	return msg
+}
+
+func buttonsFromInlineKeyboard(keyboard [][]types.TelegramInlineKeyboardButton) [][]Button {
+	buttons := make([][]Button, len(keyboard))
+	for i, row := range keyboard {
+		for _, b := range row {
+			buttons[i] = append(buttons[i], Button{Text: b.Text, Data: b.CallbackData})
+		}
+	}
+	return buttons
+}
+
+// repliesFromKeyboard tells the main menu apart from other keyboards.
+func repliesFromKeyboard(markup *types.TelegramReplyKeyboardMarkup) ([][]string, bool) {
+	if reflect.DeepEqual(*markup, types.DefaultReplyMarkup) {
+		return nil, true
+	}
+	return markup.Keyboard, false
+}
@@ -0,0 +1,85 @@
+package channel
+
+import (
+	"context"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/service"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+)
+
+// Telegram delivers messages with the Bot API.
+var Telegram Channel = telegramChannel{}
+
+type telegramChannel struct{}
+
+func (telegramChannel) Send(ctx context.Context, chatId int64, msg *Message) error {
+	var err error
+	switch {
+	case msg.Document != nil && msg.Document.Image:
+		_, err = service.Telegram.SendPhoto(ctx, &service.TelegramSendPhotoParams{
+			ChatId:      chatId,
+			Photo:       &service.TelegramInputFile{Name: msg.Document.Name, Data: msg.Document.Data},
+			Caption:     msg.Text,
+			ParseMode:   service.TelegramParseModeHTML,
+			ReplyMarkup: telegramReplyMarkup(msg),
+		})
+	case msg.Document != nil:
+		_, err = service.Telegram.SendDocument(ctx, &service.TelegramSendDocumentParams{
+			ChatId:      chatId,
+			Document:    &service.TelegramInputFile{Name: msg.Document.Name, Data: msg.Document.Data},
+			Caption:     msg.Text,
+			ParseMode:   service.TelegramParseModeHTML,
+			ReplyMarkup: telegramReplyMarkup(msg),
+		})
+	default:
+		_, err = service.Telegram.SendMessage(ctx, &service.TelegramSendMessageParams{
+			ChatId:      chatId,
+			ParseMode:   service.TelegramParseModeHTML,
+			Text:        msg.Text,
+			ReplyMarkup: telegramReplyMarkup(msg),
+		})
+	}
+	return err
+}
+
+func (telegramChannel) Edit(ctx context.Context, chatId, messageId int64, msg *Message) error {
+	// Only an inline keyboard can be edited
+	var replyMarkup any
+	if len(msg.Buttons) > 0 {
+		replyMarkup = telegramReplyMarkup(msg)
+	}
+	return service.Telegram.EditMessageText(ctx, &service.TelegramEditMessageTextParams{
+		ChatId:      chatId,
+		MessageId:   messageId,
+		ParseMode:   service.TelegramParseModeHTML,
+		Text:        msg.Text,
+		ReplyMarkup: replyMarkup,
+	})
+}
+
+func (telegramChannel) Delete(ctx context.Context, chatId, messageId int64) error {
+	return service.Telegram.DeleteMessage(ctx, &service.TelegramDeleteMessageParams{
+		ChatId:    chatId,
+		MessageId: messageId,
+	})
+}
+
+// telegramReplyMarkup returns nil, not a typed nil, when there is no markup,
+// so that reply_markup is left out.
+func telegramReplyMarkup(msg *Message) any {
+	switch {
+	case len(msg.Buttons) > 0:
+		keyboard := make([][]types.TelegramInlineKeyboardButton, len(msg.Buttons))
+		for i, row := range msg.Buttons {
+			for _, b := range row {
+				keyboard[i] = append(keyboard[i], types.TelegramInlineKeyboardButton{Text: b.Text, CallbackData: b.Data})
+			}
+		}
+		return types.TelegramInlineKeyboardMarkup{InlineKeyboard: keyboard}
+	case len(msg.Replies) > 0:
+		return types.TelegramReplyKeyboardMarkup{Keyboard: msg.Replies, ResizeKeyboard: true}
+	case msg.Menu:
+		return types.DefaultReplyMarkup
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package channel
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"mime"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/service"
+)
+
+// WhatsApp delivers messages with the WhatsApp Cloud API.
+var WhatsApp Channel = &whatsappChannel{}
+
+var ErrWhatsAppNotConfigured = errors.New("whatsapp is not configured")
+
+// WhatsApp chats are numbered from 2^52 up. Telegram IDs have at most 52
+// significant bits, so the two never collide and a chat ID tells its channel.
+const whatsappChatIdBase int64 = 1 << 52
+
+// Longest E.164 phone number
+const whatsappMaxDigits = 15
+
+// IsWhatsApp reports whether the chat is on WhatsApp.
+func IsWhatsApp(chatId int64) bool {
+	return chatId >= whatsappChatIdBase
+}
+
+// WhatsAppChatId returns the chat ID of a WhatsApp ID, the phone number in
+// international format without "+".
+func WhatsAppChatId(waId string) (int64, error) {
+	if len(waId) == 0 || len(waId) > whatsappMaxDigits {
+		return 0, fmt.Errorf("invalid WhatsApp ID %q", waId)
+	}
+	number, err := strconv.ParseInt(waId, 10, 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("invalid WhatsApp ID %q", waId)
+	}
+	return whatsappChatIdBase + number, nil
+}
+
+// WhatsAppNumber returns the phone number of a WhatsApp chat.
+func WhatsAppNumber(chatId int64) string {
+	return strconv.FormatInt(chatId-whatsappChatIdBase, 10)
+}
+
+// Limits of interactive messages
+const (
+	whatsappMaxButtons     = 3
+	whatsappMaxButtonTitle = 20
+	whatsappMaxRows        = 10
+	whatsappMaxRowTitle    = 24
+	whatsappMaxRowDesc     = 72
+	whatsappMaxBody        = 1024
+)
+
+// Prefixes of reply IDs, telling a button's callback data from a suggested reply
+const (
+	whatsappCallbackPrefix = "cb:"
+	whatsappTextPrefix     = "msg:"
+)
+
+type whatsappOption struct {
+	Id    string
+	Title string
+}
+
+type whatsappChannel struct{}
+
+func (w *whatsappChannel) Send(ctx context.Context, chatId int64, msg *Message) error {
+	if service.WhatsApp == nil {
+		return ErrWhatsAppNotConfigured
+	}
+	to := WhatsAppNumber(chatId)
+	text := whatsappText(msg.Text)
+
+	if msg.Document != nil {
+		return w.sendDocument(ctx, to, text, msg.Document)
+	}
+
+	var options []whatsappOption
+	for _, row := range msg.Buttons {
+		for _, b := range row {
+			options = append(options, whatsappOption{Id: whatsappCallbackPrefix + b.Data, Title: b.Text})
+		}
+	}
+	for _, row := range msg.Replies {
+		for _, r := range row {
+			options = append(options, whatsappOption{Id: whatsappTextPrefix + r, Title: r})
+		}
+	}
+
+	// Too many choices for a list, number them and read the number back
+	if len(options) > whatsappMaxRows {
+		ids := make([]string, len(options))
+		var textB strings.Builder
+		textB.WriteString(text)
+		textB.WriteString("\n")
+		for i, o := range options {
+			ids[i] = o.Id
+			textB.WriteString(fmt.Sprintf("\n%d. %s", i+1, o.Title))
+		}
+		textB.WriteString("\n\n_Balas dengan nomor pilihan_")
+		if err := setWhatsAppMenu(ctx, chatId, ids); err != nil {
+			return err
+		}
+		return w.sendText(ctx, to, textB.String())
+	}
+	if err := setWhatsAppMenu(ctx, chatId, nil); err != nil {
+		return err
+	}
+
+	if len(options) == 0 {
+		return w.sendText(ctx, to, text)
+	}
+
+	// An interactive body is short, send a long text on its own
+	body := text
+	if body == "" || utf8.RuneCountInString(body) > whatsappMaxBody {
+		if body != "" {
+			if err := w.sendText(ctx, to, body); err != nil {
+				return err
+			}
+		}
+		body = "Pilih:"
+	}
+
+	interactive := &service.WhatsAppInteractive{
+		Body: service.WhatsAppInteractiveBody{Text: body},
+	}
+	if len(options) <= whatsappMaxButtons && fitButtons(options) {
+		interactive.Type = "button"
+		for _, o := range options {
+			button := service.WhatsAppButton{Type: "reply"}
+			button.Reply.Id = o.Id
+			button.Reply.Title = o.Title
+			interactive.Action.Buttons = append(interactive.Action.Buttons, button)
+		}
+	} else {
+		interactive.Type = "list"
+		interactive.Action.Button = "Pilih"
+		rows := make([]service.WhatsAppRow, len(options))
+		for i, o := range options {
+			rows[i] = service.WhatsAppRow{Id: o.Id, Title: truncate(o.Title, whatsappMaxRowTitle)}
+			if utf8.RuneCountInString(o.Title) > whatsappMaxRowTitle {
+				rows[i].Description = truncate(o.Title, whatsappMaxRowDesc)
+			}
+		}
+		interactive.Action.Sections = []service.WhatsAppSection{{Rows: rows}}
+	}
+
+	return service.WhatsApp.SendMessage(ctx, &service.WhatsAppSendMessageParams{
+		To:          to,
+		Type:        "interactive",
+		Interactive: interactive,
+	})
+}
+
+// Edit does nothing, sent messages can't be edited.
+func (w *whatsappChannel) Edit(ctx context.Context, chatId, messageId int64, msg *Message) error {
+	return nil
+}
+
+// Delete does nothing, only the sender can delete a message.
+func (w *whatsappChannel) Delete(ctx context.Context, chatId, messageId int64) error {
+	return nil
+}
+
+func (w *whatsappChannel) sendText(ctx context.Context, to, text string) error {
+	return service.WhatsApp.SendMessage(ctx, &service.WhatsAppSendMessageParams{
+		To:   to,
+		Type: "text",
+		Text: &service.WhatsAppText{Body: text},
+	})
+}
+
+func (w *whatsappChannel) sendDocument(ctx context.Context, to, caption string, doc *Document) error {
+	mimeType := mime.TypeByExtension(filepath.Ext(doc.Name))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	mediaId, err := service.WhatsApp.UploadMedia(ctx, doc.Name, mimeType, doc.Data)
+	if err != nil {
+		return err
+	}
+
+	params := &service.WhatsAppSendMessageParams{To: to}
+	if doc.Image {
+		params.Type = "image"
+		params.Image = &service.WhatsAppMedia{Id: mediaId, Caption: caption}
+	} else {
+		params.Type = "document"
+		params.Document = &service.WhatsAppMedia{Id: mediaId, Caption: caption, Filename: doc.Name}
+	}
+	return service.WhatsApp.SendMessage(ctx, params)
+}
+
+// setWhatsAppMenu keeps the reply IDs of the numbered menu sent to a chat,
+// or forgets the menu if ids is nil.
+func setWhatsAppMenu(ctx context.Context, chatId int64, ids []string) error {
+	if ids == nil {
+		return database.Sqlc.DeleteWhatsAppMenu(ctx, chatId)
+	}
+	replyIds, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	return database.Sqlc.UpsertWhatsAppMenu(ctx, &database.UpsertWhatsAppMenuParams{
+		ID:        chatId,
+		ReplyIds:  replyIds,
+		UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+}
+
+// WhatsAppInput turns what a WhatsApp user sent into the text or callback
+// data of an update. replyId is the ID of the button or list row they picked,
+// if any. A number typed after a numbered menu picks from that menu.
+func WhatsAppInput(ctx context.Context, chatId int64, text, replyId string) (value string, callback bool, err error) {
+	if replyId == "" {
+		n, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil || n < 1 {
+			return text, false, nil
+		}
+
+		menu, err := database.Sqlc.GetWhatsAppMenu(ctx, chatId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return text, false, nil
+			}
+			return "", false, err
+		}
+		var ids []string
+		if err := json.Unmarshal(menu.ReplyIds, &ids); err != nil {
+			return "", false, err
+		}
+		if n > len(ids) {
+			return text, false, nil
+		}
+		replyId = ids[n-1]
+	}
+
+	if data, ok := strings.CutPrefix(replyId, whatsappCallbackPrefix); ok {
+		return data, true, nil
+	}
+	return strings.TrimPrefix(replyId, whatsappTextPrefix), false, nil
+}
+
+func fitButtons(options []whatsappOption) bool {
+	for _, o := range options {
+		if utf8.RuneCountInString(o.Title) > whatsappMaxButtonTitle {
+			return false
+		}
+	}
+	return true
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
+
+var whatsappFormatting = strings.NewReplacer(
+	"<b>", "*", "</b>", "*",
+	"<strong>", "*", "</strong>", "*",
+	"<i>", "_", "</i>", "_",
+	"<em>", "_", "</em>", "_",
+	"<s>", "~", "</s>", "~",
+	"<code>", "`", "</code>", "`",
+	"<pre>", "```", "</pre>", "```",
+)
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// whatsappText converts Telegram HTML to WhatsApp formatting.
+func whatsappText(s string) string {
+	s = whatsappFormatting.Replace(s)
+	s = htmlTagRegex.ReplaceAllString(s, "")
+	return html.UnescapeString(s)
+}
@@ -3,8 +3,10 @@ package config
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fidrasofyan/digiflazz-bot/internal/util"
 	"github.com/joho/godotenv"
@@ -15,8 +17,12 @@ type Config struct {
 	AppHost                     string
 	AppPort                     string
 	AppName                     string
+	AppLocation                 *time.Location
 	TelegramBotToken            string
+	TelegramApiBaseUrl          string
+	TelegramMaxRetries          int64
 	TelegramAllowedIds          []int64
+	TelegramAdminIds            []int64
 	DigiflazzBaseUrl            string
 	DigiflazzUsername           string
 	DigiflazzApiKey             string
@@ -24,6 +30,35 @@ type Config struct {
 	WebhookURL                  string
 	TelegramWebhookSecretToken  string
 	DigiflazzWebhookSecretToken string
+	TrxLimitMaxPrice            int64
+	TrxLimitMaxDailyTotal       int64
+	TrxLimitMaxDailyCount       int64
+	PinRequiredAbove            int64
+	PinMaxAttempts              int64
+	PinLockDuration             time.Duration
+	DuplicateTrxWindow          time.Duration
+	TelegramUpdateTTL           time.Duration
+	RateLimitPerMinute          int64
+	RateLimitExpensivePerMinute int64
+	TelegramMode                string
+	TrxFailoverMaxAttempts      int64
+	TrxFailoverMaxPriceDelta    int64
+	TrxStatusPollInterval       time.Duration
+	ProductListPageSize         int64
+	ProductListSort             string
+	ConversationIdleTimeout     time.Duration
+	ReceiptAutoSend             []string
+	ReceiptHeader               []string
+	ReceiptFooter               []string
+	SellingPriceMarkup          int64
+	CustomerRegistration        string
+	WalletTopupMin              int64
+	WalletTopupInstructions     string
+	WhatsAppApiBaseUrl          string
+	WhatsAppPhoneNumberId       string
+	WhatsAppAccessToken         string
+	WhatsAppAppSecret           string
+	WhatsAppVerifyToken         string
 }
 
 var Cfg *Config
@@ -37,17 +72,21 @@ func MustLoadConfig() {
 		os.Exit(1)
 	}
 	os.Setenv("TZ", os.Getenv("APP_TIMEZONE"))
+	appLocation, err := time.LoadLocation(os.Getenv("APP_TIMEZONE"))
+	if err != nil {
+		fmt.Printf("invalid APP_TIMEZONE: %v", err)
+		os.Exit(1)
+	}
 
 	// Telegram allowed ids
-	telegramAllowedIdsStr := strings.Split(os.Getenv("TELEGRAM_ALLOWED_IDS"), ",")
-	telegramAllowedIds := make([]int64, len(telegramAllowedIdsStr))
-	for i := range telegramAllowedIdsStr {
-		num, err := strconv.ParseInt(strings.TrimSpace(telegramAllowedIdsStr[i]), 10, 64)
-		if err != nil {
-			fmt.Printf("invalid TELEGRAM_ALLOWED_IDS: %s", os.Getenv("TELEGRAM_ALLOWED_IDS"))
-			os.Exit(1)
+	telegramAllowedIds := mustParseIdsEnv("TELEGRAM_ALLOWED_IDS", true)
+
+	// Telegram admin ids (optional). Admins are always allowed.
+	telegramAdminIds := mustParseIdsEnv("TELEGRAM_ADMIN_IDS", false)
+	for _, id := range telegramAdminIds {
+		if !slices.Contains(telegramAllowedIds, id) {
+			telegramAllowedIds = append(telegramAllowedIds, id)
 		}
-		telegramAllowedIds[i] = num
 	}
 
 	Cfg = &Config{
@@ -55,8 +94,12 @@ func MustLoadConfig() {
 		AppHost:                     os.Getenv("APP_HOST"),
 		AppPort:                     os.Getenv("APP_PORT"),
 		AppName:                     os.Getenv("APP_NAME"),
+		AppLocation:                 appLocation,
 		TelegramBotToken:            os.Getenv("TELEGRAM_BOT_TOKEN"),
+		TelegramApiBaseUrl:          strings.TrimRight(os.Getenv("TELEGRAM_API_BASE_URL"), "/"),
+		TelegramMaxRetries:          mustParseInt64Env("TELEGRAM_MAX_RETRIES", 3),
 		TelegramAllowedIds:          telegramAllowedIds,
+		TelegramAdminIds:            telegramAdminIds,
 		DigiflazzBaseUrl:            os.Getenv("DIGIFLAZZ_BASE_URL"),
 		DigiflazzUsername:           os.Getenv("DIGIFLAZZ_USERNAME"),
 		DigiflazzApiKey:             os.Getenv("DIGIFLAZZ_API_KEY"),
@@ -64,6 +107,60 @@ func MustLoadConfig() {
 		WebhookURL:                  os.Getenv("WEBHOOK_URL"),
 		TelegramWebhookSecretToken:  os.Getenv("TELEGRAM_WEBHOOK_SECRET_TOKEN"),
 		DigiflazzWebhookSecretToken: os.Getenv("DIGIFLAZZ_WEBHOOK_SECRET_TOKEN"),
+		TrxLimitMaxPrice:            mustParseInt64Env("TRX_LIMIT_MAX_PRICE", 0),
+		TrxLimitMaxDailyTotal:       mustParseInt64Env("TRX_LIMIT_MAX_DAILY_TOTAL", 0),
+		TrxLimitMaxDailyCount:       mustParseInt64Env("TRX_LIMIT_MAX_DAILY_COUNT", 0),
+		PinRequiredAbove:            mustParseInt64Env("PIN_REQUIRED_ABOVE", 0),
+		PinMaxAttempts:              mustParseInt64Env("PIN_MAX_ATTEMPTS", 3),
+		PinLockDuration:             time.Duration(mustParseInt64Env("PIN_LOCK_MINUTES", 30)) * time.Minute,
+		DuplicateTrxWindow:          time.Duration(mustParseInt64Env("DUPLICATE_TRX_WINDOW_MINUTES", 10)) * time.Minute,
+		TelegramUpdateTTL:           time.Duration(mustParseInt64Env("TELEGRAM_UPDATE_TTL_HOURS", 24)) * time.Hour,
+		RateLimitPerMinute:          mustParseInt64Env("RATE_LIMIT_PER_MINUTE", 30),
+		RateLimitExpensivePerMinute: mustParseInt64Env("RATE_LIMIT_EXPENSIVE_PER_MINUTE", 6),
+		TelegramMode:                os.Getenv("TELEGRAM_MODE"),
+		TrxFailoverMaxAttempts:      mustParseInt64Env("TRX_FAILOVER_MAX_ATTEMPTS", 0),
+		TrxFailoverMaxPriceDelta:    mustParseInt64Env("TRX_FAILOVER_MAX_PRICE_DELTA", 0),
+		TrxStatusPollInterval:       time.Duration(mustParseInt64Env("TRX_STATUS_POLL_SECONDS", 60)) * time.Second,
+		ProductListPageSize:         mustParseInt64Env("PRODUCT_LIST_PAGE_SIZE", 10),
+		ProductListSort:             os.Getenv("PRODUCT_LIST_SORT"),
+		ConversationIdleTimeout:     time.Duration(mustParseInt64Env("CONVERSATION_IDLE_MINUTES", 15)) * time.Minute,
+		ReceiptAutoSend:             mustParseReceiptFormatsEnv("RECEIPT_AUTO_SEND"),
+		ReceiptHeader:               parseLinesEnv("RECEIPT_HEADER"),
+		ReceiptFooter:               parseLinesEnv("RECEIPT_FOOTER"),
+		SellingPriceMarkup:          mustParseInt64Env("SELLING_PRICE_MARKUP", 0),
+		CustomerRegistration:        os.Getenv("CUSTOMER_REGISTRATION"),
+		WalletTopupMin:              mustParseInt64Env("WALLET_TOPUP_MIN", 10000),
+		WalletTopupInstructions:     strings.ReplaceAll(os.Getenv("WALLET_TOPUP_INSTRUCTIONS"), `\n`, "\n"),
+		WhatsAppApiBaseUrl:          strings.TrimRight(os.Getenv("WHATSAPP_API_BASE_URL"), "/"),
+		WhatsAppPhoneNumberId:       os.Getenv("WHATSAPP_PHONE_NUMBER_ID"),
+		WhatsAppAccessToken:         os.Getenv("WHATSAPP_ACCESS_TOKEN"),
+		WhatsAppAppSecret:           os.Getenv("WHATSAPP_APP_SECRET"),
+		WhatsAppVerifyToken:         os.Getenv("WHATSAPP_VERIFY_TOKEN"),
+	}
+
+	// Telegram Bot API, can be a local Bot API server
+	if Cfg.TelegramApiBaseUrl == "" {
+		Cfg.TelegramApiBaseUrl = "https://api.telegram.org"
+	}
+
+	// Telegram mode
+	if Cfg.TelegramMode == "" {
+		Cfg.TelegramMode = "webhook"
+	}
+
+	// WhatsApp Cloud API
+	if Cfg.WhatsAppApiBaseUrl == "" {
+		Cfg.WhatsAppApiBaseUrl = "https://graph.facebook.com/v21.0"
+	}
+
+	// Customers are added by admins unless registration is open
+	if Cfg.CustomerRegistration == "" {
+		Cfg.CustomerRegistration = "closed"
+	}
+
+	// Product list sort
+	if Cfg.ProductListSort == "" {
+		Cfg.ProductListSort = "price"
 	}
 
 	// Validate
@@ -111,15 +208,54 @@ func MustLoadConfig() {
 		fmt.Println("missing env variable: DATABASE_URL")
 		os.Exit(1)
 	}
-	if Cfg.WebhookURL == "" {
+	if Cfg.TelegramMode != "webhook" && Cfg.TelegramMode != "polling" {
+		fmt.Printf("invalid TELEGRAM_MODE: %s", Cfg.TelegramMode)
+		os.Exit(1)
+	}
+	// Webhook is only needed when Telegram pushes updates to us
+	if Cfg.TelegramMode == "webhook" && Cfg.WebhookURL == "" {
 		fmt.Println("missing env variable: WEBHOOK_URL")
 		os.Exit(1)
 	}
-	if Cfg.TelegramWebhookSecretToken == "" {
+	if Cfg.TelegramMode == "webhook" && Cfg.TelegramWebhookSecretToken == "" {
 		fmt.Println("missing env variable: TELEGRAM_WEBHOOK_SECRET_TOKEN")
 		os.Exit(1)
 	}
-	if Cfg.DigiflazzWebhookSecretToken == "" {
+	if Cfg.PinMaxAttempts == 0 {
+		fmt.Println("invalid PIN_MAX_ATTEMPTS: must be greater than 0")
+		os.Exit(1)
+	}
+	if Cfg.TrxStatusPollInterval == 0 {
+		fmt.Println("invalid TRX_STATUS_POLL_SECONDS: must be greater than 0")
+		os.Exit(1)
+	}
+	// Keep a page well below Telegram's 4096 characters limit
+	if Cfg.ProductListPageSize < 1 || Cfg.ProductListPageSize > 20 {
+		fmt.Println("invalid PRODUCT_LIST_PAGE_SIZE: must be between 1 and 20")
+		os.Exit(1)
+	}
+	if Cfg.ProductListSort != "price" && Cfg.ProductListSort != "name" && Cfg.ProductListSort != "status" {
+		fmt.Printf("invalid PRODUCT_LIST_SORT: %s", Cfg.ProductListSort)
+		os.Exit(1)
+	}
+	if Cfg.CustomerRegistration != "open" && Cfg.CustomerRegistration != "closed" {
+		fmt.Printf("invalid CUSTOMER_REGISTRATION: %s", Cfg.CustomerRegistration)
+		os.Exit(1)
+	}
+	// WhatsApp is enabled by its phone number ID, then everything else is required
+	if Cfg.WhatsAppPhoneNumberId != "" {
+		for key, value := range map[string]string{
+			"WHATSAPP_ACCESS_TOKEN": Cfg.WhatsAppAccessToken,
+			"WHATSAPP_APP_SECRET":   Cfg.WhatsAppAppSecret,
+			"WHATSAPP_VERIFY_TOKEN": Cfg.WhatsAppVerifyToken,
+		} {
+			if value == "" {
+				fmt.Printf("missing env variable: %s\n", key)
+				os.Exit(1)
+			}
+		}
+	}
+	if Cfg.TelegramMode == "webhook" && Cfg.DigiflazzWebhookSecretToken == "" {
 		fmt.Println("missing env variable: DIGIFLAZZ_WEBHOOK_SECRET_TOKEN")
 		os.Exit(1)
 	}
@@ -134,3 +270,81 @@ func MustLoadConfig() {
 		Cfg.TelegramWebhookSecretToken = secretToken
 	}
 }
+
+// mustParseIdsEnv parses a comma-separated list of Telegram IDs.
+func mustParseIdsEnv(key string, required bool) []int64 {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		if required {
+			fmt.Printf("missing env variable: %s\n", key)
+			os.Exit(1)
+		}
+		return []int64{}
+	}
+
+	idsStr := strings.Split(value, ",")
+	ids := make([]int64, len(idsStr))
+	for i := range idsStr {
+		num, err := strconv.ParseInt(strings.TrimSpace(idsStr[i]), 10, 64)
+		if err != nil {
+			fmt.Printf("invalid %s: %s", key, value)
+			os.Exit(1)
+		}
+		ids[i] = num
+	}
+	return ids
+}
+
+// mustParseReceiptFormatsEnv parses a comma-separated list of receipt formats.
+// Defaults to "png", "none" disables sending receipts automatically.
+func mustParseReceiptFormatsEnv(key string) []string {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	if value == "" {
+		return []string{"png"}
+	}
+	if value == "none" {
+		return []string{}
+	}
+
+	var formats []string
+	for _, format := range strings.Split(value, ",") {
+		format = strings.TrimSpace(format)
+		if format != "png" && format != "pdf" {
+			fmt.Printf("invalid %s: %s", key, value)
+			os.Exit(1)
+		}
+		if !slices.Contains(formats, format) {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+// parseLinesEnv splits a multi-line value, e.g. "Jl. Merdeka 1\nTelp 0812".
+func parseLinesEnv(key string) []string {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return []string{}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(value, `\n`, "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return lines
+}
+
+// mustParseInt64Env parses a non-negative integer, falling back to the default when empty.
+func mustParseInt64Env(key string, fallback int64) int64 {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+
+	num, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || num < 0 {
+		fmt.Printf("invalid %s: %s", key, value)
+		os.Exit(1)
+	}
+	return num
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/bot"
+	"github.com/fidrasofyan/digiflazz-bot/internal/channel"
+	"github.com/fidrasofyan/digiflazz-bot/internal/receipt"
+	"github.com/fidrasofyan/digiflazz-bot/internal/service"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+// ApprovalCallbackPrefix prefixes the callback data of the admin approval buttons.
+// Format: _approval:<approve|reject>:<approval_id>
+const ApprovalCallbackPrefix = "_approval:"
+
+var (
+	approvalStatusPending  = "pending"
+	approvalStatusApproved = "approved"
+	approvalStatusRejected = "rejected"
+)
+
+var approvalChatLocks sync.Map
+
+// lockApprovalChat locks the approvals of a chat and returns the unlock function.
+func lockApprovalChat(chatId int64) func() {
+	mu, _ := approvalChatLocks.LoadOrStore(chatId, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// requestApproval stores an approval request and asks every admin to decide on it.
+func requestApproval(ctx context.Context, from *types.TelegramUser, trxData *trxData) error {
+	approval, err := database.Sqlc.CreateTransactionApproval(ctx, &database.CreateTransactionApprovalParams{
+		ChatID:       from.Id,
+		BuyerSkuCode: trxData.Code,
+		CustomerNo:   trxData.Number,
+		Price:        trxData.Price,
+		Reason:       trxData.Reason,
+		Status:       approvalStatusPending,
+		CreatedAt:    sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	var textB strings.Builder
+	textB.WriteString("<b>Permintaan persetujuan</b>\n\n")
+	textB.WriteString(fmt.Sprintf(
+		"Dari: %s (<code>%d</code>)\n",
+		html.EscapeString(strings.TrimSpace(from.FirstName+" "+from.LastName)),
+		from.Id,
+	))
+	textB.WriteString(fmt.Sprintf("Kode: %s\n", trxData.Code))
+	textB.WriteString(fmt.Sprintf("Tujuan: %s\n", trxData.Number))
+	textB.WriteString(util.Sprintf("Harga: Rp %d\n", trxData.Price))
+	textB.WriteString(fmt.Sprintf("Alasan: %s", trxData.Reason))
+	if trxData.Duplicate {
+		textB.WriteString("\n⚠️ Transaksi yang sama sudah pernah dikirim")
+	}
+
+	adminIds, err := bot.ChatsWithRole(ctx, bot.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	for _, adminId := range adminIds {
+		err := channel.Send(ctx, adminId, &channel.Message{
+			Text: textB.String(),
+			Buttons: [][]channel.Button{
+				{
+					{Text: "✅ Setujui", Data: fmt.Sprintf("%sapprove:%d", ApprovalCallbackPrefix, approval.ID)},
+					{Text: "❌ Tolak", Data: fmt.Sprintf("%sreject:%d", ApprovalCallbackPrefix, approval.ID)},
+				},
+			},
+		})
+		if err != nil {
+			log.Printf("Error sending message: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Approval handles the admin decision on an approval request.
+func Approval(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
+	// Answer callback query
+	go func() {
+		acqCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		service.Telegram.AnswerCallbackQuery(acqCtx, &service.TelegramAnswerCallbackQueryParams{
+			CallbackQueryId: req.CallbackQuery.Id,
+		})
+	}()
+
+	adminId := req.CallbackQuery.From.Id
+
+	data := strings.Split(strings.TrimPrefix(req.CallbackQuery.Data, ApprovalCallbackPrefix), ":")
+	if len(data) != 2 {
+		return nil, util.NewError(fmt.Errorf("invalid approval callback data: %s", req.CallbackQuery.Data))
+	}
+	approvalId, err := strconv.ParseInt(data[1], 10, 64)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+
+	status := approvalStatusRejected
+	if data[0] == "approve" {
+		status = approvalStatusApproved
+	}
+
+	// Decide atomically, so an approval can only be executed once
+	approval, err := database.Sqlc.DecideTransactionApproval(ctx, &database.DecideTransactionApprovalParams{
+		Status:    status,
+		DecidedBy: &adminId,
+		DecidedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:        approvalId,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &types.TelegramResponse{
+				Method:    types.TelegramMethodEditMessageText,
+				MessageId: req.CallbackQuery.Message.MessageId,
+				ChatId:    req.CallbackQuery.Message.Chat.Id,
+				ParseMode: types.TelegramParseModeHTML,
+				Text:      html.EscapeString(req.CallbackQuery.Message.Text) + "\n\n<i>Permintaan sudah diproses</i>",
+			}, nil
+		}
+		return nil, util.NewError(err)
+	}
+
+	var resultText string
+	var result *trxResult
+	if approval.Status == approvalStatusApproved {
+		// Approvals of the same user are executed one at a time, so that
+		// several approvals can't together exceed what the admin agreed to
+		unlock := lockApprovalChat(approval.ChatID)
+		defer unlock()
+
+		// The admin approved the limits as they were when the request was made.
+		// If other transactions have changed them since, ask for a new approval.
+		limitReason, err := checkTrxLimits(ctx, approval.ChatID, approval.Price)
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+		if limitReason != "" && limitReason != approval.Reason {
+			resultText = fmt.Sprintf(
+				"<i>%s ke %s tidak diproses. %s Silakan ajukan ulang.</i>",
+				approval.BuyerSkuCode,
+				approval.CustomerNo,
+				limitReason,
+			)
+		} else {
+			result, err = createTransaction(ctx, approval.ChatID, &trxData{
+				Code:   approval.BuyerSkuCode,
+				Number: approval.CustomerNo,
+				Price:  approval.Price,
+			})
+			if err != nil {
+				return nil, util.NewError(err)
+			}
+			resultText = "✅ Disetujui admin. " + result.Text
+		}
+	} else {
+		resultText = fmt.Sprintf("<i>%s ke %s ditolak admin</i>", approval.BuyerSkuCode, approval.CustomerNo)
+	}
+
+	// Notify requester
+	err = channel.Send(ctx, approval.ChatID, &channel.Message{
+		Text: resultText,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	} else if result != nil && result.Success() {
+		if err := receipt.AutoSend(ctx, approval.ChatID, result.RefID); err != nil {
+			log.Printf("Error sending receipt %s: %v", result.RefID, err)
+		}
+	}
+
+	return &types.TelegramResponse{
+		Method:    types.TelegramMethodEditMessageText,
+		MessageId: req.CallbackQuery.Message.MessageId,
+		ChatId:    req.CallbackQuery.Message.Chat.Id,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      html.EscapeString(req.CallbackQuery.Message.Text) + "\n\n" + resultText,
+	}, nil
+}
@@ -3,22 +3,33 @@ package handler
 import (
 	"context"
 	"errors"
+	"fmt"
+	"html"
 
 	"github.com/fidrasofyan/digiflazz-bot/internal/service"
 	"github.com/fidrasofyan/digiflazz-bot/internal/types"
 	"github.com/fidrasofyan/digiflazz-bot/internal/util"
 )
 
+// CheckBalance shows the Digiflazz deposit, or the wallet balance to customers.
 func CheckBalance(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
-	res, err := service.DigiflazzCheckBalance(ctx)
+	customer, err := isCustomer(ctx, req.Message.Chat.Id)
 	if err != nil {
-		var digiflazzError *service.DigiflazzErrorResponse
-		if errors.As(err, &digiflazzError) {
+		return nil, util.NewError(err)
+	}
+	if customer {
+		return Wallet(ctx, req)
+	}
+
+	res, err := service.Digiflazz.CheckBalance(ctx)
+	if err != nil {
+		var digiflazzError *service.DigiflazzError
+		if errors.As(err, &digiflazzError) && digiflazzError.Rejected() {
 			return &types.TelegramResponse{
 				Method:      types.TelegramMethodSendMessage,
 				ChatId:      req.Message.Chat.Id,
 				ParseMode:   types.TelegramParseModeHTML,
-				Text:        digiflazzError.Error(),
+				Text:        fmt.Sprintf("%s\n\n<i>%s</i>", html.EscapeString(digiflazzError.Error()), service.LookupDigiflazzRC(digiflazzError.RC).Note()),
 				ReplyMarkup: types.DefaultReplyMarkup,
 			}, nil
 		}
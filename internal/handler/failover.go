@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/service"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+// PurchaseTimeout is how long handling an update that may create a transaction
+// can take: every failover attempt may wait for Digiflazz up to its transaction timeout.
+func PurchaseTimeout() time.Duration {
+	attempts := time.Duration(config.Cfg.TrxFailoverMaxAttempts + 1)
+	return attempts*service.DefaultDigiflazzTimeouts.Transaction + 15*time.Second
+}
+
+// createTransaction sends the transaction and, when it fails with a retryable
+// response code, retries with the cheapest equivalent product from another
+// seller (up to TRX_FAILOVER_MAX_ATTEMPTS times). Each attempt gets its own ref_id.
+func createTransaction(ctx context.Context, chatId int64, trx *trxData) (*trxResult, error) {
+	current := *trx
+	tried := []string{strings.ToLower(current.Code)}
+	var failoverB strings.Builder
+
+	for attempt := 0; ; attempt++ {
+		result, err := sendTransaction(ctx, chatId, &current)
+		if err != nil {
+			return nil, err
+		}
+		// An unconfirmed attempt may still succeed, another seller could
+		// deliver the same product twice
+		rc := result.RC
+		if result.Unconfirmed || rc == nil || rc.Category != service.DigiflazzRCRetryable || attempt >= int(config.Cfg.TrxFailoverMaxAttempts) {
+			result.Text = failoverText(&failoverB, &current, result, result.Text)
+			return result, nil
+		}
+
+		// Find the next cheapest equivalent product
+		products, err := database.Sqlc.ListEquivalentPrepaidProducts(ctx, &database.ListEquivalentPrepaidProductsParams{
+			BuyerSkuCode:  trx.Code,
+			MaxPriceDelta: config.Cfg.TrxFailoverMaxPriceDelta,
+		})
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		next := slices.IndexFunc(products, func(p *database.PrepaidProduct) bool {
+			if slices.Contains(tried, strings.ToLower(p.BuyerSkuCode)) {
+				return false
+			}
+			// Skip sellers that are in cut-off or out of stock
+			return productAvailability(p.UnlimitedStock, p.Stock, p.StartCutOff, p.EndCutOff, now) == ""
+		})
+		if next == -1 {
+			result.Text = failoverText(&failoverB, &current, result, result.Text)
+			return result, nil
+		}
+
+		product := products[next]
+
+		// The user agreed to the original price. A more expensive product
+		// must still be within the limits and not need the PIN.
+		stopReason, err := failoverStopReason(ctx, chatId, trx, product)
+		if err != nil {
+			return nil, err
+		}
+		if stopReason != "" {
+			log.Printf("Transaction failover: %s failed with %s, not retrying with %s: %s", current.Code, rc, product.BuyerSkuCode, stopReason)
+			failoverB.WriteString(fmt.Sprintf(
+				"⚠️ %s gagal (%s), tidak dialihkan ke %s. %s\n",
+				current.Code,
+				rc.Message,
+				product.BuyerSkuCode,
+				stopReason,
+			))
+			result.Text = failoverText(&failoverB, &current, result, result.Text)
+			return result, nil
+		}
+
+		log.Printf("Transaction failover: %s failed with %s, retrying with %s", current.Code, rc, product.BuyerSkuCode)
+		failoverB.WriteString(fmt.Sprintf(
+			"⚠️ %s gagal (%s), dialihkan ke %s (%s)\n",
+			current.Code,
+			rc.Message,
+			product.BuyerSkuCode,
+			product.SellerName,
+		))
+
+		tried = append(tried, strings.ToLower(product.BuyerSkuCode))
+		current.Code = product.BuyerSkuCode
+		current.Price = product.Price
+	}
+}
+
+// failoverStopReason returns why the transaction must not fail over to the
+// product, or an empty string if it may.
+func failoverStopReason(ctx context.Context, chatId int64, trx *trxData, product *database.PrepaidProduct) (string, error) {
+	if product.Price <= trx.Price {
+		return "", nil
+	}
+
+	limitReason, err := checkTrxLimits(ctx, chatId, product.Price)
+	if err != nil || limitReason != "" {
+		return limitReason, err
+	}
+
+	userPin, err := database.Sqlc.GetUserPin(ctx, chatId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	if userPin.ID == 0 {
+		return "", nil
+	}
+	charged, err := chargedPrice(ctx, chatId, trx.Price)
+	if err != nil {
+		return "", err
+	}
+	failoverCharged, err := chargedPrice(ctx, chatId, product.Price)
+	if err != nil {
+		return "", err
+	}
+	if charged <= config.Cfg.PinRequiredAbove && failoverCharged > config.Cfg.PinRequiredAbove {
+		return "Harga pengganti memerlukan PIN.", nil
+	}
+
+	return "", nil
+}
+
+// failoverText adds the failover history and the seller that handled the last attempt.
+func failoverText(failoverB *strings.Builder, trx *trxData, result *trxResult, text string) string {
+	if failoverB.Len() == 0 {
+		return text
+	}
+
+	rc := result.RC
+	if result.Unconfirmed {
+		failoverB.WriteString(util.Sprintf("⏳ Dikirim ke %s, harga Rp %d, menunggu konfirmasi\n", trx.Code, trx.Price))
+	} else if rc != nil && (rc.Category == service.DigiflazzRCSuccess || rc.Category == service.DigiflazzRCPending) {
+		failoverB.WriteString(util.Sprintf("✅ Diproses oleh %s, harga Rp %d\n", trx.Code, trx.Price))
+	}
+	return failoverB.String() + "\n" + text
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/database/repository"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+// Limit shows or sets the transaction limits of a user (admin only).
+//
+//	limit <chat_id>
+//	limit <chat_id> default
+//	limit <chat_id> <max_trx_price> <max_daily_total> <max_daily_count>
+func Limit(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
+	args := strings.Fields(strings.ToLower(req.Message.Text))[1:]
+	userId, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+
+	switch len(args) {
+	// Reset to default
+	case 2:
+		err := database.Sqlc.DeleteUserLimit(ctx, userId)
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+
+	// Set limits
+	case 4:
+		values := make([]int64, 3)
+		for i := range values {
+			values[i], err = strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				return nil, util.NewError(err)
+			}
+		}
+
+		_, err := database.Sqlc.UpsertUserLimit(ctx, &database.UpsertUserLimitParams{
+			ID:            userId,
+			MaxTrxPrice:   values[0],
+			MaxDailyTotal: values[1],
+			MaxDailyCount: values[2],
+			UpdatedAt:     sql.NullTime{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+	}
+
+	limits, err := repository.GetUserLimits(ctx, userId)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+	summary, err := repository.GetDailyTransactionSummary(ctx, userId)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+
+	var textB strings.Builder
+	textB.WriteString(fmt.Sprintf("<b>Limit %d</b>", userId))
+	if limits.IsDefault {
+		textB.WriteString(" <i>(default)</i>")
+	}
+	textB.WriteString("\n\n")
+	textB.WriteString(fmt.Sprintf("Maks per transaksi: %s\n", formatLimit(limits.MaxTrxPrice, "Rp ")))
+	textB.WriteString(fmt.Sprintf("Maks total harian: %s\n", formatLimit(limits.MaxDailyTotal, "Rp ")))
+	textB.WriteString(fmt.Sprintf("Maks jumlah harian: %s\n\n", formatLimit(limits.MaxDailyCount, "")))
+	textB.WriteString(util.Sprintf("Hari ini: Rp %d (%d transaksi)", summary.Total, summary.Count))
+
+	return &types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      req.Message.Chat.Id,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        textB.String(),
+		ReplyMarkup: types.DefaultReplyMarkup,
+	}, nil
+}
+
+func formatLimit(value int64, prefix string) string {
+	if value == 0 {
+		return "tanpa batas"
+	}
+	return prefix + util.Sprintf("%d", value)
+}
+
+// checkTrxLimits returns the reason why a transaction with the given price
+// exceeds the user's limits, or an empty string if it doesn't.
+func checkTrxLimits(ctx context.Context, chatId int64, price int64) (string, error) {
+	limits, err := repository.GetUserLimits(ctx, chatId)
+	if err != nil {
+		return "", err
+	}
+
+	if limits.MaxTrxPrice > 0 && price > limits.MaxTrxPrice {
+		return util.Sprintf("Harga melebihi batas per transaksi (Rp %d).", limits.MaxTrxPrice), nil
+	}
+
+	if limits.MaxDailyTotal == 0 && limits.MaxDailyCount == 0 {
+		return "", nil
+	}
+
+	summary, err := repository.GetDailyTransactionSummary(ctx, chatId)
+	if err != nil {
+		return "", err
+	}
+
+	if limits.MaxDailyTotal > 0 && summary.Total+price > limits.MaxDailyTotal {
+		return util.Sprintf(
+			"Total transaksi hari ini melebihi batas harian (Rp %d dari Rp %d).",
+			summary.Total+price,
+			limits.MaxDailyTotal,
+		), nil
+	}
+
+	if limits.MaxDailyCount > 0 && summary.Count+1 > limits.MaxDailyCount {
+		return util.Sprintf("Jumlah transaksi hari ini sudah mencapai batas (%d transaksi).", limits.MaxDailyCount), nil
+	}
+
+	return "", nil
+}
@@ -19,7 +19,7 @@ func NotFound(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramRe
 		}
 
 		// Answer callback query
-		err = service.TelegramAnswerCallbackQuery(ctx, &service.TelegramAnswerCallbackQueryParams{
+		err = service.Telegram.AnswerCallbackQuery(ctx, &service.TelegramAnswerCallbackQueryParams{
 			CallbackQueryId: req.CallbackQuery.Id,
 		})
 		if err != nil {
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/channel"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+var pinRegex = regexp.MustCompile(`^\d{4,6}$`)
+
+// Pin sets, changes or removes the transaction PIN of the user.
+//
+//	pin <new_pin>
+//	pin <old_pin> <new_pin>
+//	hapus pin <old_pin>
+func Pin(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.Message.Chat.Id
+
+	// The message contains the PIN, don't leave it in the chat
+	deleteMessage(req.Message)
+
+	userPin, err := database.Sqlc.GetUserPin(ctx, chatId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, util.NewError(err)
+	}
+
+	fields := strings.Fields(strings.ToLower(req.Message.Text))
+	remove := fields[0] == "hapus"
+	pins := fields[1:]
+	if remove {
+		pins = fields[2:]
+	}
+	for _, pin := range pins {
+		if !pinRegex.MatchString(pin) {
+			return &types.TelegramResponse{
+				Method:      types.TelegramMethodSendMessage,
+				ChatId:      chatId,
+				ParseMode:   types.TelegramParseModeHTML,
+				Text:        "<i>PIN harus 4-6 digit angka</i>",
+				ReplyMarkup: types.DefaultReplyMarkup,
+			}, nil
+		}
+	}
+
+	var text string
+	switch {
+	// Remove PIN
+	case remove:
+		if userPin.ID == 0 {
+			text = "<i>PIN belum diatur</i>"
+			break
+		}
+		check, err := checkPin(ctx, userPin, pins[0])
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+		if !check.OK {
+			text = check.Text
+			break
+		}
+		err = database.Sqlc.DeleteUserPin(ctx, chatId)
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+		text = "<i>PIN dihapus</i>"
+
+	// Set PIN
+	case len(pins) == 1:
+		if userPin.ID != 0 {
+			text = "<i>PIN sudah diatur. Untuk mengganti: pin lama baru</i>"
+			break
+		}
+		err := setPin(ctx, chatId, pins[0])
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+		text = "<i>PIN berhasil diatur</i>"
+
+	// Change PIN
+	default:
+		if userPin.ID == 0 {
+			text = "<i>PIN belum diatur</i>"
+			break
+		}
+		check, err := checkPin(ctx, userPin, pins[0])
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+		if !check.OK {
+			text = check.Text
+			break
+		}
+		err = setPin(ctx, chatId, pins[1])
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+		text = "<i>PIN berhasil diganti</i>"
+	}
+
+	return &types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      chatId,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        text,
+		ReplyMarkup: types.DefaultReplyMarkup,
+	}, nil
+}
+
+func setPin(ctx context.Context, chatId int64, pin string) error {
+	pinHash, err := util.HashPin(pin)
+	if err != nil {
+		return err
+	}
+	return database.Sqlc.UpsertUserPin(ctx, &database.UpsertUserPinParams{
+		ID:        chatId,
+		PinHash:   pinHash,
+		UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+}
+
+// pinLockedText returns the message to show when the account is locked,
+// or an empty string if it isn't.
+func pinLockedText(userPin *database.UserPin) string {
+	if userPin.ID == 0 || !userPin.LockedUntil.Valid || time.Now().After(userPin.LockedUntil.Time) {
+		return ""
+	}
+	return fmt.Sprintf(
+		"<i>Akun terkunci karena PIN salah. Coba lagi setelah %s</i>",
+		userPin.LockedUntil.Time.Format("15:04 MST"),
+	)
+}
+
+type pinCheckResult struct {
+	OK     bool
+	Locked bool
+	// Text is the message to show when the PIN is not accepted
+	Text string
+}
+
+// checkPin verifies the PIN, counting wrong attempts and locking the account
+// once config.Cfg.PinMaxAttempts is reached.
+func checkPin(ctx context.Context, userPin *database.UserPin, pin string) (*pinCheckResult, error) {
+	if text := pinLockedText(userPin); text != "" {
+		return &pinCheckResult{Locked: true, Text: text}, nil
+	}
+
+	now := time.Now()
+
+	if pinRegex.MatchString(pin) && util.VerifyPin(pin, userPin.PinHash) {
+		if userPin.FailedAttempts > 0 || userPin.LockedUntil.Valid {
+			err := database.Sqlc.ResetUserPinFailedAttempts(ctx, &database.ResetUserPinFailedAttemptsParams{
+				UpdatedAt: sql.NullTime{Time: now, Valid: true},
+				ID:        userPin.ID,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &pinCheckResult{OK: true}, nil
+	}
+
+	failedAttempts, err := database.Sqlc.IncrementUserPinFailedAttempts(ctx, &database.IncrementUserPinFailedAttemptsParams{
+		UpdatedAt: sql.NullTime{Time: now, Valid: true},
+		ID:        userPin.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if failedAttempts >= config.Cfg.PinMaxAttempts {
+		lockedUntil := now.Add(config.Cfg.PinLockDuration)
+		err := database.Sqlc.LockUserPin(ctx, &database.LockUserPinParams{
+			LockedUntil: sql.NullTime{Time: lockedUntil, Valid: true},
+			UpdatedAt:   sql.NullTime{Time: now, Valid: true},
+			ID:          userPin.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &pinCheckResult{
+			Locked: true,
+			Text:   fmt.Sprintf("<i>PIN salah. Akun terkunci sampai %s</i>", lockedUntil.Format("15:04 MST")),
+		}, nil
+	}
+
+	return &pinCheckResult{
+		Text: fmt.Sprintf("<i>PIN salah. Sisa percobaan: %d</i>", config.Cfg.PinMaxAttempts-failedAttempts),
+	}, nil
+}
+
+// deleteMessage deletes a user message in the background.
+func deleteMessage(message *types.TelegramMessage) {
+	go func() {
+		dmCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		channel.Delete(dmCtx, message.Chat.Id, message.MessageId)
+	}()
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+)
+
+// parseCutOff parses a Digiflazz cut-off time such as "23:45".
+func parseCutOff(value *string) (time.Duration, bool) {
+	if value == nil || *value == "" {
+		return 0, false
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		t, err := time.Parse(layout, *value)
+		if err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+		}
+	}
+	return 0, false
+}
+
+// cutOffWindow returns the daily cut-off window of a seller, e.g. "23:45-00:15",
+// or an empty string when there is none.
+func cutOffWindow(start, end *string) string {
+	startTime, ok1 := parseCutOff(start)
+	endTime, ok2 := parseCutOff(end)
+	if !ok1 || !ok2 || startTime == endTime {
+		return ""
+	}
+	return fmt.Sprintf("%s-%s", *start, *end)
+}
+
+// inCutOff reports whether now, in APP_TIMEZONE, is inside the cut-off window.
+// The window may cross midnight.
+func inCutOff(start, end *string, now time.Time) bool {
+	startTime, ok1 := parseCutOff(start)
+	endTime, ok2 := parseCutOff(end)
+	if !ok1 || !ok2 || startTime == endTime {
+		return false
+	}
+
+	now = now.In(config.Cfg.AppLocation)
+	current := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	if startTime < endTime {
+		return current >= startTime && current < endTime
+	}
+	return current >= startTime || current < endTime
+}
+
+// productAvailability explains why a product can't be bought right now,
+// or returns an empty string when it can.
+func productAvailability(unlimitedStock bool, stock int64, start, end *string, now time.Time) string {
+	if inCutOff(start, end, now) {
+		return fmt.Sprintf("Seller sedang cut off (%s)", cutOffWindow(start, end))
+	}
+	if !unlimitedStock && stock <= 0 {
+		return "Stok habis"
+	}
+	return ""
+}
@@ -2,499 +2,249 @@ package handler
 
 import (
 	"context"
-	"database/sql"
-	"encoding/json"
-	"errors"
-	"fmt"
+	"slices"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/fidrasofyan/digiflazz-bot/database"
-	"github.com/fidrasofyan/digiflazz-bot/database/repository"
-	"github.com/fidrasofyan/digiflazz-bot/internal/service"
+	"github.com/fidrasofyan/digiflazz-bot/internal/bot"
+	"github.com/fidrasofyan/digiflazz-bot/internal/channel"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
 	"github.com/fidrasofyan/digiflazz-bot/internal/types"
 	"github.com/fidrasofyan/digiflazz-bot/internal/util"
 )
 
 var productListCmd = "daftar produk"
 
-func ProductList(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
-	var chatId int64
+// productListConversation drills down from category to brand and type,
+// then shows the products page by page.
+var productListConversation = &bot.Conversation[productListPage]{
+	Command: productListCmd,
+	Initial: "category",
+	States: map[string]*bot.State[productListPage]{
+		"category": {
+			Input: bot.InputCallback,
+			Enter: productListCategories,
+			Handle: func(ctx context.Context, c *bot.Conv[productListPage]) (*types.TelegramResponse, error) {
+				c.Data.Category = c.CallbackData()
+				return c.Push(ctx, "brand")
+			},
+		},
+		"brand": {
+			Input: bot.InputCallback,
+			Enter: productListBrands,
+			Handle: func(ctx context.Context, c *bot.Conv[productListPage]) (*types.TelegramResponse, error) {
+				c.Data.Brand = c.CallbackData()
+				return c.Push(ctx, "type")
+			},
+		},
+		"type": {
+			Input: bot.InputCallback,
+			Enter: productListTypes,
+			Handle: func(ctx context.Context, c *bot.Conv[productListPage]) (*types.TelegramResponse, error) {
+				c.Data.Type = c.CallbackData()
+				c.Data.Sort = config.Cfg.ProductListSort
+				c.Data.Page = 1
+				customer, err := isCustomer(ctx, c.ChatId)
+				if err != nil {
+					return nil, util.NewError(err)
+				}
+				c.Data.Customer = customer
+				return c.Push(ctx, "page")
+			},
+		},
+		"page": {
+			Input:  bot.InputCallback,
+			Enter:  productListPageEnter,
+			Handle: productListPageHandle,
+			// Close, keep the current page without buttons
+			Cancel: func(ctx context.Context, c *bot.Conv[productListPage]) (*types.TelegramResponse, error) {
+				text, err := productListPageText(ctx, &c.Data)
+				if err != nil {
+					return nil, util.NewError(err)
+				}
+				return c.Reply(text, nil), nil
+			},
+		},
+	},
+}
 
-	// Is it callback query?
-	if req.CallbackQuery != nil {
-		chatId = req.CallbackQuery.From.Id
-	} else {
-		chatId = req.Message.Chat.Id
-	}
+func ProductList(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
+	return productListConversation.Handle(ctx, req)
+}
 
-	// Get chat
-	chat, err := repository.TelegramGetChat(ctx, chatId)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+func productListCategories(ctx context.Context, c *bot.Conv[productListPage]) (*types.TelegramResponse, error) {
+	// Get categories
+	categories, err := database.Sqlc.GetCategories(ctx)
+	if err != nil {
 		return nil, util.NewError(err)
 	}
 
-	if chat.ID == 0 {
-		// Create new chat
-		chat, err = repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
-			ID:      chatId,
-			Command: productListCmd,
-			Step:    1,
-		})
-		if err != nil {
-			return nil, util.NewError(err)
-		}
+	if len(categories) == 0 {
+		c.End()
+		return c.Reply("<i>Tidak ada produk</i>", types.DefaultReplyMarkup), nil
 	}
 
-	switch chat.Step {
-	// Step 1
-	case 1:
-		// Get categories
-		categories, err := database.Sqlc.GetCategories(ctx)
-		if err != nil {
-			return nil, util.NewError(err)
-		}
-
-		if len(categories) == 0 {
-			// Delete chat
-			if repository.TelegramDeleteChat(ctx, chatId) != nil {
-				return nil, util.NewError(err)
-			}
-
-			return &types.TelegramResponse{
-				Method:      types.TelegramMethodSendMessage,
-				ChatId:      chatId,
-				ParseMode:   types.TelegramParseModeHTML,
-				Text:        "<i>Tidak ada produk</i>",
-				ReplyMarkup: types.DefaultReplyMarkup,
-			}, nil
-		}
-
-		inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, len(categories)+1)
+	inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, len(categories)+1)
 
-		for i, category := range categories {
-			inlineKeyboard[i] = []types.TelegramInlineKeyboardButton{
-				{
-					Text:         category,
-					CallbackData: category,
-				},
-			}
-		}
-
-		inlineKeyboard[len(categories)] = []types.TelegramInlineKeyboardButton{
+	for i, category := range categories {
+		inlineKeyboard[i] = []types.TelegramInlineKeyboardButton{
 			{
-				Text: "❌", CallbackData: "cancel",
-			},
-		}
-
-		// Set step
-		_, err = repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
-			ID:      chatId,
-			Command: productListCmd,
-			Step:    2,
-		})
-		if err != nil {
-			return nil, util.NewError(err)
-		}
-
-		return &types.TelegramResponse{
-			Method:    types.TelegramMethodSendMessage,
-			ChatId:    chatId,
-			ParseMode: types.TelegramParseModeHTML,
-			Text:      "Pilih kategori:",
-			ReplyMarkup: types.TelegramInlineKeyboardMarkup{
-				InlineKeyboard: inlineKeyboard,
+				Text:         category,
+				CallbackData: category,
 			},
-		}, nil
-
-	// Step 2
-	case 2:
-		// It must be callback query
-		if req.CallbackQuery == nil {
-			// Delete chat
-			if repository.TelegramDeleteChat(ctx, chatId) != nil {
-				return nil, util.NewError(err)
-			}
-
-			return &types.TelegramResponse{
-				Method:    types.TelegramMethodSendMessage,
-				ChatId:    chatId,
-				ParseMode: types.TelegramParseModeHTML,
-				Text:      "<i>Perintah tidak valid</i>",
-			}, nil
 		}
+	}
 
-		// Answer callback query
-		go func() {
-			acqCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			service.TelegramAnswerCallbackQuery(acqCtx, &service.TelegramAnswerCallbackQueryParams{
-				CallbackQueryId: req.CallbackQuery.Id,
-			})
-		}()
-
-		// Cancel
-		if req.CallbackQuery.Data == "cancel" {
-			// Delete chat
-			if repository.TelegramDeleteChat(ctx, chatId) != nil {
-				return nil, util.NewError(err)
-			}
-
-			return &types.TelegramResponse{
-				Method:    types.TelegramMethodEditMessageText,
-				MessageId: req.CallbackQuery.Message.MessageId,
-				ChatId:    chatId,
-				ParseMode: types.TelegramParseModeHTML,
-				Text:      "<i>Dibatalkan</i>",
-			}, nil
-		}
+	inlineKeyboard[len(categories)] = []types.TelegramInlineKeyboardButton{
+		{
+			Text: "❌", CallbackData: "cancel",
+		},
+	}
 
-		// Get brands
-		category := req.CallbackQuery.Data
-		brands, err := database.Sqlc.GetBrandsByCategory(ctx, category)
-		if err != nil {
-			return nil, util.NewError(err)
-		}
+	return c.Reply("Pilih kategori:", types.TelegramInlineKeyboardMarkup{
+		InlineKeyboard: inlineKeyboard,
+	}), nil
+}
 
-		inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, len(brands)+1)
+func productListBrands(ctx context.Context, c *bot.Conv[productListPage]) (*types.TelegramResponse, error) {
+	// Get brands
+	brands, err := database.Sqlc.GetBrandsByCategory(ctx, c.Data.Category)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
 
-		for i, brand := range brands {
-			inlineKeyboard[i] = []types.TelegramInlineKeyboardButton{
-				{
-					Text:         brand,
-					CallbackData: fmt.Sprintf("%s,%s", category, brand),
-				},
-			}
-		}
+	inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, len(brands)+1)
 
-		inlineKeyboard[len(brands)] = []types.TelegramInlineKeyboardButton{
-			{
-				Text: "⬅️", CallbackData: "back",
-			},
+	for i, brand := range brands {
+		inlineKeyboard[i] = []types.TelegramInlineKeyboardButton{
 			{
-				Text: "❌", CallbackData: "cancel",
+				Text:         brand,
+				CallbackData: brand,
 			},
 		}
+	}
 
-		// Set previous reply markup
-		previousReplyMarkupB, err := json.Marshal(req.CallbackQuery.Message.ReplyMarkup)
-		if err != nil {
-			return nil, util.NewError(err)
-		}
-		err = repository.TelegramSetReplyMarkup(ctx, &repository.TelegramSetReplyMarkupParams{
-			ID:          chatId,
-			Step:        1,
-			ReplyMarkup: previousReplyMarkupB,
-		})
-		if err != nil {
-			return nil, util.NewError(err)
-		}
+	inlineKeyboard[len(brands)] = []types.TelegramInlineKeyboardButton{
+		{
+			Text: "⬅️", CallbackData: "back",
+		},
+		{
+			Text: "❌", CallbackData: "cancel",
+		},
+	}
 
-		// Set step
-		_, err = repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
-			ID:      chatId,
-			Command: productListCmd,
-			Step:    3,
-		})
-		if err != nil {
-			return nil, util.NewError(err)
-		}
+	return c.Reply("Pilih provider:", types.TelegramInlineKeyboardMarkup{
+		InlineKeyboard: inlineKeyboard,
+	}), nil
+}
 
-		return &types.TelegramResponse{
-			Method:    types.TelegramMethodEditMessageText,
-			MessageId: req.CallbackQuery.Message.MessageId,
-			ChatId:    req.CallbackQuery.Message.Chat.Id,
-			ParseMode: types.TelegramParseModeHTML,
-			Text:      "Pilih provider:",
-			ReplyMarkup: types.TelegramInlineKeyboardMarkup{
-				InlineKeyboard: inlineKeyboard,
-			},
-		}, nil
-
-	// Step 3
-	case 3:
-		// It must be callback query
-		if req.CallbackQuery == nil {
-			// Delete chat
-			if repository.TelegramDeleteChat(ctx, chatId) != nil {
-				return nil, util.NewError(err)
-			}
-
-			return &types.TelegramResponse{
-				Method:    types.TelegramMethodSendMessage,
-				ChatId:    chatId,
-				ParseMode: types.TelegramParseModeHTML,
-				Text:      "<i>Perintah tidak valid</i>",
-			}, nil
-		}
+func productListTypes(ctx context.Context, c *bot.Conv[productListPage]) (*types.TelegramResponse, error) {
+	// Get product types
+	productTypes, err := database.Sqlc.GetTypesByCategoryAndBrand(ctx, &database.GetTypesByCategoryAndBrandParams{
+		Category: c.Data.Category,
+		Brand:    c.Data.Brand,
+	})
+	if err != nil {
+		return nil, util.NewError(err)
+	}
 
-		// Answer callback query
-		go func() {
-			acqCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			service.TelegramAnswerCallbackQuery(acqCtx, &service.TelegramAnswerCallbackQueryParams{
-				CallbackQueryId: req.CallbackQuery.Id,
-			})
-		}()
-
-		// Cancel
-		if req.CallbackQuery.Data == "cancel" {
-			// Delete chat
-			if repository.TelegramDeleteChat(ctx, chatId) != nil {
-				return nil, util.NewError(err)
-			}
-
-			return &types.TelegramResponse{
-				Method:    types.TelegramMethodEditMessageText,
-				MessageId: req.CallbackQuery.Message.MessageId,
-				ChatId:    chatId,
-				ParseMode: types.TelegramParseModeHTML,
-				Text:      "<i>Dibatalkan</i>",
-			}, nil
-		}
+	inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, len(productTypes)+1)
 
-		// Back
-		if req.CallbackQuery.Data == "back" {
-			// Set step
-			_, err := repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
-				ID:      chatId,
-				Command: productListCmd,
-				Step:    2,
-			})
-			if err != nil {
-				return nil, util.NewError(err)
-			}
-
-			replyMarkup := &types.TelegramInlineKeyboardMarkup{}
-			err = json.Unmarshal(chat.ReplyMarkup1, replyMarkup)
-			if err != nil {
-				return nil, util.NewError(err)
-			}
-
-			return &types.TelegramResponse{
-				Method:      types.TelegramMethodEditMessageText,
-				MessageId:   req.CallbackQuery.Message.MessageId,
-				ChatId:      req.CallbackQuery.Message.Chat.Id,
-				ParseMode:   types.TelegramParseModeHTML,
-				Text:        "Pilih kategori:",
-				ReplyMarkup: replyMarkup,
-			}, nil
+	for i, pt := range productTypes {
+		inlineKeyboard[i] = []types.TelegramInlineKeyboardButton{
+			{
+				Text:         pt,
+				CallbackData: pt,
+			},
 		}
+	}
 
-		data := strings.Split(req.CallbackQuery.Data, ",")
-		productCategory := data[0]
-		productBrand := data[1]
+	inlineKeyboard[len(productTypes)] = []types.TelegramInlineKeyboardButton{
+		{
+			Text: "⬅️", CallbackData: "back",
+		},
+		{
+			Text: "❌", CallbackData: "cancel",
+		},
+	}
 
-		// Get product types
-		productTypes, err := database.Sqlc.GetTypesByCategoryAndBrand(ctx, &database.GetTypesByCategoryAndBrandParams{
-			Category: productCategory,
-			Brand:    productBrand,
-		})
-		if err != nil {
-			return nil, util.NewError(err)
-		}
+	return c.Reply("Pilih tipe:", types.TelegramInlineKeyboardMarkup{
+		InlineKeyboard: inlineKeyboard,
+	}), nil
+}
 
-		inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, len(productTypes)+1)
+func productListPageEnter(ctx context.Context, c *bot.Conv[productListPage]) (*types.TelegramResponse, error) {
+	// Get prepaid products
+	prepaidProducts, err := database.Sqlc.GetPrepaidProducts(ctx, &database.GetPrepaidProductsParams{
+		Category: c.Data.Category,
+		Brand:    c.Data.Brand,
+		Type:     c.Data.Type,
+	})
+	if err != nil {
+		return nil, util.NewError(err)
+	}
 
-		for i, pt := range productTypes {
-			inlineKeyboard[i] = []types.TelegramInlineKeyboardButton{
-				{
-					Text:         pt,
-					CallbackData: fmt.Sprintf("%s,%s,%s", productCategory, productBrand, pt),
-				},
-			}
-		}
+	text, replyMarkup := renderProductListPage(&c.Data, prepaidProducts, int(config.Cfg.ProductListPageSize))
+	return c.Reply(text, replyMarkup), nil
+}
 
-		inlineKeyboard[len(productTypes)] = []types.TelegramInlineKeyboardButton{
-			{
-				Text: "⬅️", CallbackData: "back",
-			},
-			{
-				Text: "❌", CallbackData: "cancel",
-			},
-		}
+func productListPageHandle(ctx context.Context, c *bot.Conv[productListPage]) (*types.TelegramResponse, error) {
+	callbackData := c.CallbackData()
+	switch {
+	// Buy, continue with the transaction
+	case strings.HasPrefix(callbackData, "buy:"):
+		productCode := strings.TrimPrefix(callbackData, "buy:")
 
-		// Set previous reply markup
-		previousReplyMarkupB, err := json.Marshal(req.CallbackQuery.Message.ReplyMarkup)
-		if err != nil {
-			return nil, util.NewError(err)
-		}
-		err = repository.TelegramSetReplyMarkup(ctx, &repository.TelegramSetReplyMarkupParams{
-			ID:          chatId,
-			Step:        2,
-			ReplyMarkup: previousReplyMarkupB,
-		})
+		// Remove the buttons from the list
+		text, err := productListPageText(ctx, &c.Data)
 		if err != nil {
 			return nil, util.NewError(err)
 		}
-
-		// Set step
-		_, err = repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
-			ID:      chatId,
-			Command: productListCmd,
-			Step:    4,
-		})
+		message := c.Req.CallbackQuery.Message
+		err = channel.Edit(ctx, message.Chat.Id, message.MessageId, &channel.Message{Text: text})
 		if err != nil {
 			return nil, util.NewError(err)
 		}
 
-		return &types.TelegramResponse{
-			Method:    types.TelegramMethodEditMessageText,
-			MessageId: req.CallbackQuery.Message.MessageId,
-			ChatId:    req.CallbackQuery.Message.Chat.Id,
-			ParseMode: types.TelegramParseModeHTML,
-			Text:      "Pilih tipe:",
-			ReplyMarkup: types.TelegramInlineKeyboardMarkup{
-				InlineKeyboard: inlineKeyboard,
-			},
-		}, nil
-
-	// Step 4
-	case 4:
-		// It must be callback query
-		if req.CallbackQuery == nil {
-			// Delete chat
-			if repository.TelegramDeleteChat(ctx, chatId) != nil {
-				return nil, util.NewError(err)
-			}
-
-			return &types.TelegramResponse{
-				Method:    types.TelegramMethodSendMessage,
-				ChatId:    chatId,
-				ParseMode: types.TelegramParseModeHTML,
-				Text:      "<i>Perintah tidak valid</i>",
-			}, nil
-		}
-
-		// Answer callback query
-		go func() {
-			acqCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			service.TelegramAnswerCallbackQuery(acqCtx, &service.TelegramAnswerCallbackQueryParams{
-				CallbackQueryId: req.CallbackQuery.Id,
-			})
-		}()
-
-		// Cancel
-		if req.CallbackQuery.Data == "cancel" {
-			// Delete chat
-			if repository.TelegramDeleteChat(ctx, chatId) != nil {
-				return nil, util.NewError(err)
-			}
-
-			return &types.TelegramResponse{
-				Method:    types.TelegramMethodEditMessageText,
-				MessageId: req.CallbackQuery.Message.MessageId,
-				ChatId:    chatId,
-				ParseMode: types.TelegramParseModeHTML,
-				Text:      "<i>Dibatalkan</i>",
-			}, nil
-		}
+		c.End()
+		return startTransaction(ctx, c.Req, c.ChatId, productCode)
 
-		// Back
-		if req.CallbackQuery.Data == "back" {
-			// Set step
-			_, err := repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
-				ID:      chatId,
-				Command: productListCmd,
-				Step:    3,
-			})
-			if err != nil {
-				return nil, util.NewError(err)
-			}
-
-			replyMarkup := &types.TelegramInlineKeyboardMarkup{}
-			err = json.Unmarshal(chat.ReplyMarkup2, replyMarkup)
-			if err != nil {
-				return nil, util.NewError(err)
-			}
-
-			return &types.TelegramResponse{
-				Method:      types.TelegramMethodEditMessageText,
-				MessageId:   req.CallbackQuery.Message.MessageId,
-				ChatId:      req.CallbackQuery.Message.Chat.Id,
-				ParseMode:   types.TelegramParseModeHTML,
-				Text:        "Pilih provider:",
-				ReplyMarkup: replyMarkup,
-			}, nil
+	// Sort, start from the first page
+	case strings.HasPrefix(callbackData, "sort:"):
+		sort := strings.TrimPrefix(callbackData, "sort:")
+		if !slices.ContainsFunc(productListSorts, func(s productListSort) bool { return s.Key == sort }) {
+			return nil, nil
 		}
+		c.Data.Sort = sort
+		c.Data.Page = 1
 
-		data := strings.Split(req.CallbackQuery.Data, ",")
-		productCategory := data[0]
-		productBrand := data[1]
-		productType := data[2]
-
-		// Get prepaid products
-		prepaidProducts, err := database.Sqlc.GetPrepaidProducts(ctx, &database.GetPrepaidProductsParams{
-			Category: productCategory,
-			Brand:    productBrand,
-			Type:     productType,
-		})
+	// Prev/Next
+	case strings.HasPrefix(callbackData, "page:"):
+		page, err := strconv.Atoi(strings.TrimPrefix(callbackData, "page:"))
 		if err != nil {
-			return nil, util.NewError(err)
-		}
-
-		textLimit := 3500
-		var textB strings.Builder
-		textB.WriteString(fmt.Sprintf("<b>%s » %s » %s</b>\n\n", productCategory, productBrand, productType))
-
-		for _, pp := range prepaidProducts {
-			var status string
-			if pp.BuyerProductStatus && pp.SellerProductStatus {
-				status = "✅"
-			} else {
-				status = "❌"
-			}
-			textB.WriteString(fmt.Sprintf("%s Kode: <code>%s</code>\n", status, pp.BuyerSkuCode))
-			textB.WriteString(fmt.Sprintf("Nama: %s\n", pp.Name))
-			textB.WriteString(fmt.Sprintf("Seller: %s\n", pp.SellerName))
-			textB.WriteString(util.Sprintf("Harga: Rp %d\n", pp.Price))
-
-			// If text is too long, send it part by part
-			if textB.Len() >= textLimit {
-				service.TelegramSendMessage(ctx, &service.TelegramSendMessageParams{
-					ChatId:    req.CallbackQuery.Message.Chat.Id,
-					ParseMode: service.TelegramParseModeHTML,
-					Text:      textB.String(),
-					LinkPreviewOptions: &types.TelegramLinkPreviewOptions{
-						IsDisabled: true,
-					},
-				})
-				textB.Reset()
-			}
-		}
-
-		// Delete chat
-		if repository.TelegramDeleteChat(ctx, chatId) != nil {
-			return nil, util.NewError(err)
-		}
-
-		if textB.Len() == 0 {
 			return nil, nil
 		}
+		c.Data.Page = page
 
-		return &types.TelegramResponse{
-			Method:    types.TelegramMethodEditMessageText,
-			MessageId: req.CallbackQuery.Message.MessageId,
-			ChatId:    req.CallbackQuery.Message.Chat.Id,
-			ParseMode: types.TelegramParseModeHTML,
-			Text:      textB.String(),
-		}, nil
-
-	// Unhandled step
 	default:
-		// Delete chat
-		if repository.TelegramDeleteChat(ctx, chatId) != nil {
-			return nil, util.NewError(err)
-		}
+		return nil, nil
+	}
 
-		return &types.TelegramResponse{
-			Method:      types.TelegramMethodSendMessage,
-			ChatId:      req.Message.Chat.Id,
-			ParseMode:   types.TelegramParseModeHTML,
-			Text:        "<i>Unhandled step</i>",
-			ReplyMarkup: types.DefaultReplyMarkup,
-		}, nil
+	return productListPageEnter(ctx, c)
+}
 
+// productListPageText renders the current page without buttons.
+func productListPageText(ctx context.Context, state *productListPage) (string, error) {
+	prepaidProducts, err := database.Sqlc.GetPrepaidProducts(ctx, &database.GetPrepaidProductsParams{
+		Category: state.Category,
+		Brand:    state.Brand,
+		Type:     state.Type,
+	})
+	if err != nil {
+		return "", err
 	}
+
+	text, _ := renderProductListPage(state, prepaidProducts, int(config.Cfg.ProductListPageSize))
+	return text, nil
 }
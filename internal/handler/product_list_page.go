@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+type productListSort struct {
+	Key   string
+	Label string
+}
+
+// Product list sort options, see PRODUCT_LIST_SORT
+var productListSorts = []productListSort{
+	{Key: "price", Label: "Harga"},
+	{Key: "name", Label: "Nama"},
+	{Key: "status", Label: "Status"},
+}
+
+// productListPage is the data of the product list conversation.
+type productListPage struct {
+	Category string `json:"category"`
+	Brand    string `json:"brand"`
+	Type     string `json:"type"`
+	Sort     string `json:"sort"`
+	Page     int    `json:"page"`
+	// Customer shows the selling price, without the seller
+	Customer bool `json:"customer,omitempty"`
+}
+
+func isProductActive(pp *database.GetPrepaidProductsRow) bool {
+	return pp.BuyerProductStatus && pp.SellerProductStatus
+}
+
+// sortPrepaidProducts sorts the products in place. Ties are ordered by price.
+func sortPrepaidProducts(products []*database.GetPrepaidProductsRow, sort string) {
+	slices.SortStableFunc(products, func(a, b *database.GetPrepaidProductsRow) int {
+		switch sort {
+		case "name":
+			if c := cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)); c != 0 {
+				return c
+			}
+		case "status":
+			// Active products first
+			if isProductActive(a) != isProductActive(b) {
+				if isProductActive(a) {
+					return -1
+				}
+				return 1
+			}
+		}
+		return cmp.Compare(a.Price, b.Price)
+	})
+}
+
+// renderProductListPage builds the text and buttons of one page of products.
+// The page is clamped to the available pages.
+func renderProductListPage(state *productListPage, products []*database.GetPrepaidProductsRow, pageSize int) (string, types.TelegramInlineKeyboardMarkup) {
+	totalPages := max((len(products)+pageSize-1)/pageSize, 1)
+	state.Page = min(max(state.Page, 1), totalPages)
+
+	sortPrepaidProducts(products, state.Sort)
+	start := (state.Page - 1) * pageSize
+	end := min(start+pageSize, len(products))
+
+	var textB strings.Builder
+	textB.WriteString(fmt.Sprintf("<b>%s » %s » %s</b>\n", state.Category, state.Brand, state.Type))
+	textB.WriteString(fmt.Sprintf("<i>Halaman %d/%d · %d produk</i>\n\n", state.Page, totalPages, len(products)))
+
+	if len(products) == 0 {
+		textB.WriteString("<i>Tidak ada produk</i>\n")
+	}
+
+	for _, pp := range products[start:end] {
+		var status string
+		if isProductActive(pp) {
+			status = "✅"
+		} else {
+			status = "❌"
+		}
+		textB.WriteString(fmt.Sprintf("%s Kode: <code>%s</code>\n", status, pp.BuyerSkuCode))
+		textB.WriteString(fmt.Sprintf("Nama: %s\n", pp.Name))
+		if state.Customer {
+			textB.WriteString(util.Sprintf("Harga: Rp %d\n", sellingPrice(pp.Price)))
+		} else {
+			textB.WriteString(fmt.Sprintf("Seller: %s\n", pp.SellerName))
+			textB.WriteString(util.Sprintf("Harga: Rp %d\n", pp.Price))
+		}
+		if window := cutOffWindow(pp.StartCutOff, pp.EndCutOff); window != "" {
+			textB.WriteString(fmt.Sprintf("Cut off: %s\n", window))
+		}
+		if !pp.UnlimitedStock && pp.Stock <= 0 {
+			textB.WriteString("Stok: habis\n")
+		}
+		textB.WriteString("\n")
+	}
+
+	var inlineKeyboard [][]types.TelegramInlineKeyboardButton
+
+	// Tap to buy, two active products per row
+	var buyRow []types.TelegramInlineKeyboardButton
+	for _, pp := range products[start:end] {
+		if !isProductActive(pp) {
+			continue
+		}
+		price := pp.Price
+		if state.Customer {
+			price = sellingPrice(price)
+		}
+		buyRow = append(buyRow, types.TelegramInlineKeyboardButton{
+			Text:         util.Sprintf("🛒 %s · %d", pp.BuyerSkuCode, price),
+			CallbackData: "buy:" + pp.BuyerSkuCode,
+		})
+		if len(buyRow) == 2 {
+			inlineKeyboard = append(inlineKeyboard, buyRow)
+			buyRow = nil
+		}
+	}
+	if len(buyRow) > 0 {
+		inlineKeyboard = append(inlineKeyboard, buyRow)
+	}
+
+	// Sort
+	sortRow := make([]types.TelegramInlineKeyboardButton, len(productListSorts))
+	for i, s := range productListSorts {
+		label := s.Label
+		if s.Key == state.Sort {
+			label = "• " + label
+		}
+		sortRow[i] = types.TelegramInlineKeyboardButton{
+			Text:         label,
+			CallbackData: "sort:" + s.Key,
+		}
+	}
+	inlineKeyboard = append(inlineKeyboard, sortRow)
+
+	// Prev/Next
+	var pageRow []types.TelegramInlineKeyboardButton
+	if state.Page > 1 {
+		pageRow = append(pageRow, types.TelegramInlineKeyboardButton{
+			Text: "◀️ Prev", CallbackData: fmt.Sprintf("page:%d", state.Page-1),
+		})
+	}
+	if state.Page < totalPages {
+		pageRow = append(pageRow, types.TelegramInlineKeyboardButton{
+			Text: "Next ▶️", CallbackData: fmt.Sprintf("page:%d", state.Page+1),
+		})
+	}
+	if len(pageRow) > 0 {
+		inlineKeyboard = append(inlineKeyboard, pageRow)
+	}
+
+	inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{
+		{
+			Text: "⬅️", CallbackData: "back",
+		},
+		{
+			Text: "❌", CallbackData: "cancel",
+		},
+	})
+
+	return textB.String(), types.TelegramInlineKeyboardMarkup{
+		InlineKeyboard: inlineKeyboard,
+	}
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+)
+
+func TestProductAvailability(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Cfg = &config.Config{AppLocation: jakarta}
+
+	str := func(s string) *string { return &s }
+	at := func(clock string) time.Time {
+		t, err := time.ParseInLocation("2006-01-02 15:04", "2026-01-10 "+clock, jakarta)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	tests := []struct {
+		name           string
+		unlimitedStock bool
+		stock          int64
+		start, end     *string
+		now            time.Time
+		want           string
+	}{
+		{
+			name:           "no cut-off",
+			unlimitedStock: true,
+			now:            at("12:00"),
+			want:           "",
+		},
+		{
+			name:           "empty cut-off",
+			unlimitedStock: true,
+			start:          str(""),
+			end:            str(""),
+			now:            at("12:00"),
+			want:           "",
+		},
+		{
+			name:           "same start and end",
+			unlimitedStock: true,
+			start:          str("00:00"),
+			end:            str("00:00"),
+			now:            at("00:00"),
+			want:           "",
+		},
+		{
+			name:           "inside window",
+			unlimitedStock: true,
+			start:          str("10:00"),
+			end:            str("11:00"),
+			now:            at("10:30"),
+			want:           "Seller sedang cut off (10:00-11:00)",
+		},
+		{
+			name:           "window end is exclusive",
+			unlimitedStock: true,
+			start:          str("10:00"),
+			end:            str("11:00"),
+			now:            at("11:00"),
+			want:           "",
+		},
+		{
+			name:           "before midnight in window across midnight",
+			unlimitedStock: true,
+			start:          str("23:45"),
+			end:            str("00:15"),
+			now:            at("23:50"),
+			want:           "Seller sedang cut off (23:45-00:15)",
+		},
+		{
+			name:           "after midnight in window across midnight",
+			unlimitedStock: true,
+			start:          str("23:45"),
+			end:            str("00:15"),
+			now:            at("00:10"),
+			want:           "Seller sedang cut off (23:45-00:15)",
+		},
+		{
+			name:           "outside window across midnight",
+			unlimitedStock: true,
+			start:          str("23:45"),
+			end:            str("00:15"),
+			now:            at("12:00"),
+			want:           "",
+		},
+		{
+			name:           "seconds in cut-off times",
+			unlimitedStock: true,
+			start:          str("23:00:00"),
+			end:            str("01:00:00"),
+			now:            at("00:30"),
+			want:           "Seller sedang cut off (23:00:00-01:00:00)",
+		},
+		{
+			name:           "now in another zone",
+			unlimitedStock: true,
+			start:          str("23:45"),
+			end:            str("00:15"),
+			now:            at("23:50").UTC(),
+			want:           "Seller sedang cut off (23:45-00:15)",
+		},
+		{
+			name:  "out of stock",
+			stock: 0,
+			now:   at("12:00"),
+			want:  "Stok habis",
+		},
+		{
+			name:  "in stock",
+			stock: 3,
+			now:   at("12:00"),
+			want:  "",
+		},
+		{
+			name:  "cut-off before stock",
+			stock: 0,
+			start: str("10:00"),
+			end:   str("11:00"),
+			now:   at("10:30"),
+			want:  "Seller sedang cut off (10:00-11:00)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := productAvailability(tt.unlimitedStock, tt.stock, tt.start, tt.end, tt.now)
+			if got != tt.want {
+				t.Errorf("productAvailability() = %q, want %q", got, tt.want)
+			}
+			if inCutOff(tt.start, tt.end, tt.now) != (got != "" && got != "Stok habis") {
+				t.Errorf("inCutOff() disagrees with productAvailability() = %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/bot"
+	"github.com/fidrasofyan/digiflazz-bot/internal/channel"
+	"github.com/fidrasofyan/digiflazz-bot/internal/receipt"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+// Receipt sends the receipt of a successful transaction as an image and a PDF.
+// "struk ref_id"
+func Receipt(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.Message.Chat.Id
+	textParts := strings.Fields(req.Message.Text)
+
+	r, trx, err := receipt.Load(ctx, textParts[1])
+	if err != nil && !errors.Is(err, sql.ErrNoRows) && !errors.Is(err, receipt.ErrNotSuccessful) {
+		return nil, util.NewError(err)
+	}
+
+	// Users can only get the receipts of their own transactions
+	if trx != nil && trx.ChatID != chatId {
+		role, err := bot.RoleOf(ctx, chatId)
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+		if role < bot.RoleAdmin {
+			trx = nil
+		}
+	}
+	if trx == nil {
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        "<i>Transaksi tidak ditemukan</i>",
+			ReplyMarkup: types.DefaultReplyMarkup,
+		}, nil
+	}
+	if errors.Is(err, receipt.ErrNotSuccessful) {
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        "<i>Struk hanya tersedia untuk transaksi sukses</i>",
+			ReplyMarkup: types.DefaultReplyMarkup,
+		}, nil
+	}
+
+	err = receipt.Send(ctx, chatId, r, []string{"png", "pdf"})
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+	return nil, nil
+}
+
+// transactionResult replies with the result of a transaction. A successful
+// transaction is followed by its receipt, see RECEIPT_AUTO_SEND.
+func transactionResult(ctx context.Context, chatId int64, result *trxResult) (*types.TelegramResponse, error) {
+	if !result.Success() {
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        result.Text,
+			ReplyMarkup: types.DefaultReplyMarkup,
+		}, nil
+	}
+
+	// Send the result first, so the receipt comes after it
+	err := channel.Send(ctx, chatId, &channel.Message{
+		Text: result.Text,
+		Menu: true,
+	})
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+
+	// The transaction is done, a missing receipt can be requested with "struk"
+	if err := receipt.AutoSend(ctx, chatId, result.RefID); err != nil {
+		log.Printf("Error sending receipt %s: %v", result.RefID, err)
+	}
+	return nil, nil
+}
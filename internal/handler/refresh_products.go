@@ -6,8 +6,8 @@ import (
 	"log"
 	"time"
 
+	"github.com/fidrasofyan/digiflazz-bot/internal/channel"
 	"github.com/fidrasofyan/digiflazz-bot/internal/job"
-	"github.com/fidrasofyan/digiflazz-bot/internal/service"
 	"github.com/fidrasofyan/digiflazz-bot/internal/types"
 )
 
@@ -20,10 +20,8 @@ func RefreshProducts(ctx context.Context, req *types.TelegramUpdate) (*types.Tel
 		if err != nil {
 			log.Printf("Error refreshing products: %v", err)
 
-			err = service.TelegramSendMessage(ctxWithTimeout, &service.TelegramSendMessageParams{
-				ChatId:    req.Message.Chat.Id,
-				ParseMode: service.TelegramParseModeHTML,
-				Text:      fmt.Sprintf("Produk gagal diperbarui. %v", err),
+			err = channel.Send(ctxWithTimeout, req.Message.Chat.Id, &channel.Message{
+				Text: fmt.Sprintf("Produk gagal diperbarui. %v", err),
 			})
 			if err != nil {
 				log.Printf("Error sending message: %v", err)
@@ -32,10 +30,8 @@ func RefreshProducts(ctx context.Context, req *types.TelegramUpdate) (*types.Tel
 			return
 		}
 
-		err = service.TelegramSendMessage(ctxWithTimeout, &service.TelegramSendMessageParams{
-			ChatId:    req.Message.Chat.Id,
-			ParseMode: service.TelegramParseModeHTML,
-			Text:      "Produk berhasil diperbarui",
+		err = channel.Send(ctxWithTimeout, req.Message.Chat.Id, &channel.Message{
+			Text: "Produk berhasil diperbarui",
 		})
 		if err != nil {
 			log.Printf("Error sending message: %v", err)
@@ -3,15 +3,19 @@ package handler
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"log"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/fidrasofyan/digiflazz-bot/database"
 	"github.com/fidrasofyan/digiflazz-bot/database/repository"
+	"github.com/fidrasofyan/digiflazz-bot/internal/bot"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/receipt"
 	"github.com/fidrasofyan/digiflazz-bot/internal/service"
 	"github.com/fidrasofyan/digiflazz-bot/internal/types"
 	"github.com/fidrasofyan/digiflazz-bot/internal/util"
@@ -23,203 +27,721 @@ var trxCmd = "_transaction"
 type trxData struct {
 	Code   string `json:"code"`
 	Number string `json:"number"`
+	Price  int64  `json:"price"`
+	Reason string `json:"reason,omitempty"`
+	// Duplicate is set when the same product was sent to the same number recently
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+// trxNumberPattern matches a destination number after removing dashes
+var trxNumberPattern = regexp.MustCompile(`^[0-9]{4,20}$`)
+
+// Errors of CreateTransaction
+var (
+	ErrProductNotFound      = errors.New("product not found")
+	ErrProductUnavailable   = errors.New("product can't be bought now")
+	ErrInvalidNumber        = errors.New("invalid destination number")
+	ErrDuplicateTransaction = errors.New("the same transaction was sent recently")
+)
+
+var (
+	trxConfirmText = "Ya"
+	trxResendText  = "Kirim ulang"
+	trxCancelText  = "Tidak"
+)
+
+// transactionConversation confirms a purchase, asks for the PIN or an admin
+// approval when needed, then sends it to Digiflazz.
+var transactionConversation = &bot.Conversation[trxData]{
+	Command: trxCmd,
+	Initial: "new",
+	States: map[string]*bot.State[trxData]{
+		// "kode_produk nomor_tujuan"
+		"new": {
+			Enter: func(ctx context.Context, c *bot.Conv[trxData]) (*types.TelegramResponse, error) {
+				textParts := strings.Fields(normalizeTrxNumber(c.Text()))
+				return confirmTransaction(ctx, c, textParts[0], textParts[1])
+			},
+		},
+		// The product was picked from the list, ask for the number
+		"number": {
+			Enter: func(ctx context.Context, c *bot.Conv[trxData]) (*types.TelegramResponse, error) {
+				return &types.TelegramResponse{
+					Method:    types.TelegramMethodSendMessage,
+					ChatId:    c.ChatId,
+					ParseMode: types.TelegramParseModeHTML,
+					Text:      fmt.Sprintf("Kode: <code>%s</code>\nMasukkan nomor tujuan:", c.Data.Code),
+					ReplyMarkup: types.TelegramReplyKeyboardMarkup{
+						ResizeKeyboard: true,
+						Keyboard: [][]string{
+							{"Cancel"},
+						},
+					},
+				}, nil
+			},
+			Input: bot.InputText,
+			Validate: func(c *bot.Conv[trxData]) string {
+				if !trxNumberPattern.MatchString(normalizeTrxNumber(c.Text())) {
+					return "<i>Nomor tujuan tidak valid, masukkan lagi atau ketik cancel</i>"
+				}
+				return ""
+			},
+			Handle: func(ctx context.Context, c *bot.Conv[trxData]) (*types.TelegramResponse, error) {
+				return confirmTransaction(ctx, c, c.Data.Code, normalizeTrxNumber(c.Text()))
+			},
+		},
+		"confirm": {
+			Handle: transactionConfirm,
+		},
+		// Limit exceeded, ask for admin approval
+		"approval": {
+			Input:  bot.InputCallback,
+			Handle: transactionApproval,
+		},
+		// PIN confirmation
+		"pin": {
+			Input:   bot.InputText,
+			Handle:  transactionPin,
+			Timeout: 5 * time.Minute,
+		},
+	},
 }
 
 func Transaction(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
-	var chatId int64
+	return transactionConversation.Handle(ctx, req)
+}
 
-	// Is it callback query?
-	if req.CallbackQuery != nil {
-		chatId = req.CallbackQuery.From.Id
-	} else {
-		chatId = req.Message.Chat.Id
+// normalizeTrxNumber removes dashes and replaces the +62 prefix with 0.
+func normalizeTrxNumber(text string) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "-", "")
+	return strings.ReplaceAll(text, "+62", "0")
+}
+
+func transactionConfirm(ctx context.Context, c *bot.Conv[trxData]) (*types.TelegramResponse, error) {
+	// Duplicates must be confirmed explicitly
+	confirmText := trxConfirmText
+	if c.Data.Duplicate {
+		confirmText = trxResendText
+	}
+
+	if c.Text() != confirmText {
+		c.End()
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      c.ChatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        "<i>Dibatalkan</i>",
+			ReplyMarkup: types.DefaultReplyMarkup,
+		}, nil
 	}
 
-	// Get chat
-	chat, err := repository.TelegramGetChat(ctx, chatId)
+	// Is PIN required?
+	userPin, err := database.Sqlc.GetUserPin(ctx, c.ChatId)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return nil, util.NewError(err)
 	}
+	charged, err := chargedPrice(ctx, c.ChatId, c.Data.Price)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+	if userPin.ID != 0 && charged > config.Cfg.PinRequiredAbove {
+		// Set state
+		c.Goto("pin")
 
-	if chat.ID == 0 {
-		// Create new chat
-		chat, err = repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
-			ID:      chatId,
-			Command: trxCmd,
-			Step:    1,
-		})
-		if err != nil {
-			return nil, util.NewError(err)
-		}
+		return &types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    c.ChatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      "Masukkan PIN:",
+			ReplyMarkup: types.TelegramReplyKeyboardRemove{
+				RemoveKeyboard: true,
+			},
+		}, nil
 	}
 
-	switch chat.Step {
-	// Step 1
-	case 1:
-		textParts := strings.Fields(req.Message.Text)
-		productCode := textParts[0]
-		destinationNumber := textParts[1]
+	// Claim state, so the confirmation can only create one transaction
+	claimed, err := c.Claim(ctx)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+	if !claimed {
+		return nil, nil
+	}
 
-		// Prepaid product exist?
-		prepaidProduct, err := database.Sqlc.GetPrepaidProductBySKUCode(ctx, productCode)
-		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+	result, err := createTransaction(ctx, c.ChatId, &c.Data)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+
+	return transactionResult(ctx, c.ChatId, result)
+}
+
+func transactionApproval(ctx context.Context, c *bot.Conv[trxData]) (*types.TelegramResponse, error) {
+	// Claim state, so only one approval request is created
+	claimed, err := c.Claim(ctx)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+	if !claimed {
+		return nil, nil
+	}
+
+	if c.CallbackData() != "request_approval" {
+		return c.Reply("<i>Dibatalkan</i>", nil), nil
+	}
+
+	err = requestApproval(ctx, &c.Req.CallbackQuery.From, &c.Data)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+
+	return c.Reply(fmt.Sprintf(
+		"<i>%s ke %s menunggu persetujuan admin...</i>",
+		c.Data.Code,
+		c.Data.Number,
+	), nil), nil
+}
+
+func transactionPin(ctx context.Context, c *bot.Conv[trxData]) (*types.TelegramResponse, error) {
+	// The message contains the PIN, don't leave it in the chat
+	deleteMessage(c.Req.Message)
+
+	pin := strings.TrimSpace(c.Text())
+	if !pinRegex.MatchString(pin) {
+		c.End()
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      c.ChatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        "<i>Dibatalkan</i>",
+			ReplyMarkup: types.DefaultReplyMarkup,
+		}, nil
+	}
+
+	userPin, err := database.Sqlc.GetUserPin(ctx, c.ChatId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, util.NewError(err)
+	}
+
+	// PIN may have been removed in the meantime
+	if userPin.ID != 0 {
+		check, err := checkPin(ctx, userPin, pin)
+		if err != nil {
 			return nil, util.NewError(err)
 		}
-		if prepaidProduct.ID == 0 {
-			// Delete step
-			err := repository.TelegramDeleteChat(ctx, chatId)
-			if err != nil {
-				return nil, util.NewError(err)
-			}
-
+		if !check.OK && !check.Locked {
+			// Let the user try again
+			return &types.TelegramResponse{
+				Method:    types.TelegramMethodSendMessage,
+				ChatId:    c.ChatId,
+				ParseMode: types.TelegramParseModeHTML,
+				Text:      check.Text,
+			}, nil
+		}
+		if check.Locked {
+			c.End()
 			return &types.TelegramResponse{
 				Method:      types.TelegramMethodSendMessage,
-				ChatId:      req.Message.Chat.Id,
+				ChatId:      c.ChatId,
 				ParseMode:   types.TelegramParseModeHTML,
-				Text:        "<i>Produk tidak ditemukan</i>",
+				Text:        check.Text,
 				ReplyMarkup: types.DefaultReplyMarkup,
 			}, nil
 		}
+	}
 
-		var prepaidProductStatus string
-		if prepaidProduct.BuyerProductStatus && prepaidProduct.SellerProductStatus {
-			prepaidProductStatus = "✅"
-		} else {
-			prepaidProductStatus = "❌"
+	// Claim state, so the confirmation can only create one transaction
+	claimed, err := c.Claim(ctx)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+	if !claimed {
+		return nil, nil
+	}
+
+	result, err := createTransaction(ctx, c.ChatId, &c.Data)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+
+	return transactionResult(ctx, c.ChatId, result)
+}
+
+// trxResult is the outcome of a transaction sent to Digiflazz.
+type trxResult struct {
+	// Text is shown to the user
+	Text  string
+	RefID string
+	// RC is nil if Digiflazz didn't answer
+	RC *service.DigiflazzRC
+	// Unconfirmed is set when Digiflazz may have received the transaction but
+	// its answer was lost. It stays pending until PollPendingTransactions checks it.
+	Unconfirmed bool
+}
+
+// unconfirmedTrxResult is the result of a transaction whose answer was lost.
+func unconfirmedTrxResult(refId string, trxData *trxData) *trxResult {
+	return &trxResult{
+		Text:        fmt.Sprintf("<i>%s ke %s sedang diproses. Status akan dikirim setelah dicek...</i>", trxData.Code, trxData.Number),
+		RefID:       refId,
+		Unconfirmed: true,
+	}
+}
+
+// Success reports whether the transaction succeeded right away.
+func (r *trxResult) Success() bool {
+	return r.RC != nil && r.RC.Category == service.DigiflazzRCSuccess
+}
+
+// sendTransaction records the transaction, sends it to Digiflazz and returns
+// the text to show to the user with the response code, if Digiflazz answered.
+func sendTransaction(ctx context.Context, chatId int64, trxData *trxData) (*trxResult, error) {
+	refId := uuid.Must(uuid.NewV7()).String()
+	now := time.Now()
+
+	customer, err := isCustomer(ctx, chatId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record transaction as pending first, so it counts toward the limits
+	if customer {
+		// Paid from the wallet, refunded if it fails
+		err = repository.CreateWalletPurchase(ctx, &repository.CreateWalletPurchaseParams{
+			RefID:        refId,
+			ChatID:       chatId,
+			BuyerSkuCode: trxData.Code,
+			CustomerNo:   trxData.Number,
+			Price:        trxData.Price,
+			SellingPrice: sellingPrice(trxData.Price),
+		})
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			return &trxResult{
+				Text: fmt.Sprintf("<i>%s ke %s dibatalkan. Saldo dompet tidak cukup.</i>", trxData.Code, trxData.Number),
+			}, nil
 		}
+	} else {
+		_, err = database.Sqlc.CreateTransaction(ctx, &database.CreateTransactionParams{
+			RefID:        refId,
+			ChatID:       chatId,
+			BuyerSkuCode: trxData.Code,
+			CustomerNo:   trxData.Number,
+			Price:        trxData.Price,
+			Status:       string(types.DigiflazzTrxStatusPending),
+			CreatedAt:    sql.NullTime{Time: now, Valid: true},
+			UpdatedAt:    sql.NullTime{Time: now, Valid: true},
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		var textB strings.Builder
-		textB.WriteString(fmt.Sprintf("Kode: %s\n", prepaidProduct.BuyerSkuCode))
-		textB.WriteString(fmt.Sprintf("Tujuan: %s\n", destinationNumber))
-		textB.WriteString(util.Sprintf("Harga: Rp %d\n\n", prepaidProduct.Price))
-		textB.WriteString(fmt.Sprintf("Seller: %s\n", prepaidProduct.SellerName))
-		textB.WriteString(fmt.Sprintf("Status: %s\n", prepaidProductStatus))
-		textB.WriteString(fmt.Sprintf("Nama: %s\n", prepaidProduct.Name))
-		textB.WriteString(fmt.Sprintf("Deskripsi: %s\n", *prepaidProduct.Description))
-		textB.WriteString("\nYakin ingin memproses?")
+	// Send to digiflazz
+	digiflazzRes, err := service.Digiflazz.CreateTrx(ctx, &service.DigiflazzCreateTrxParams{
+		RefID:        refId,
+		BuyerSKUCode: trxData.Code,
+		CustomerNo:   trxData.Number,
+	})
+	if err != nil {
+		var digiflazzError *service.DigiflazzError
+		if errors.As(err, &digiflazzError) && digiflazzError.Rejected() {
+			rc := service.LookupDigiflazzRC(digiflazzError.RC)
+			log.Printf("Transaction %s rejected: %s: %s", refId, rc, digiflazzError.Message)
 
-		// Set step
-		trxData := &trxData{
-			Code:   productCode,
-			Number: destinationNumber,
+			err = repository.UpdateTransaction(ctx, &repository.UpdateTransactionParams{
+				RefID:   refId,
+				Price:   trxData.Price,
+				Status:  string(types.DigiflazzTrxStatusFailed),
+				RC:      digiflazzError.RC,
+				Message: digiflazzError.Message,
+			})
+			if err != nil {
+				return nil, err
+			}
+			refund, err := refundText(ctx, refId)
+			if err != nil {
+				return nil, err
+			}
+			// The RC note is meant for staff
+			note := fmt.Sprintf("\n\n<i>%s</i>", rc.Note())
+			if customer {
+				note = ""
+			}
+			return &trxResult{
+				Text: fmt.Sprintf(
+					"%s ke %s Gagal. Keterangan: %s%s%s",
+					trxData.Code,
+					trxData.Number,
+					html.EscapeString(digiflazzError.Message),
+					note,
+					refund,
+				),
+				RefID: refId,
+				RC:    rc,
+			}, nil
 		}
-		trxDataB, err := json.Marshal(trxData)
+
+		// Digiflazz may have received it, it's pending, not failed
+		log.Printf("Transaction %s unconfirmed: %v", refId, err)
+		return unconfirmedTrxResult(refId, trxData), nil
+	}
+
+	err = repository.UpdateTransaction(ctx, &repository.UpdateTransactionParams{
+		RefID:   refId,
+		Price:   int64(digiflazzRes.Data.Price),
+		Status:  string(digiflazzRes.Data.Status),
+		RC:      digiflazzRes.Data.RC,
+		SN:      digiflazzRes.Data.SN,
+		Message: digiflazzRes.Data.Message,
+	})
+	if err != nil {
+		// Digiflazz answered, but the answer isn't stored. The transaction
+		// is still pending, PollPendingTransactions asks again.
+		log.Printf("Transaction %s unconfirmed: %v", refId, err)
+		return unconfirmedTrxResult(refId, trxData), nil
+	}
+
+	rc := service.LookupDigiflazzRC(digiflazzRes.Data.RC)
+	log.Printf("Transaction %s %s: %s", refId, digiflazzRes.Data.Status, rc)
+
+	if rc.Category == service.DigiflazzRCPending {
+		return &trxResult{
+			Text:  fmt.Sprintf("<i>%s ke %s sedang diproses...</i>", trxData.Code, trxData.Number),
+			RefID: refId,
+			RC:    rc,
+		}, nil
+	}
+
+	var sn string
+	if digiflazzRes.Data.SN != nil {
+		sn = *digiflazzRes.Data.SN
+	}
+
+	var textB strings.Builder
+	textB.WriteString(fmt.Sprintf(
+		"%s ke %s %s.",
+		digiflazzRes.Data.BuyerSKUCode,
+		digiflazzRes.Data.CustomerNo,
+		digiflazzRes.Data.Status,
+	))
+	textB.WriteString(receipt.SNText(sn))
+	textB.WriteString(fmt.Sprintf("Waktu: %s. ", time.Now().Format("2 Jan 2006 15:04:05 MST")))
+	textB.WriteString(fmt.Sprintf("Keterangan: %s", digiflazzRes.Data.Message))
+	if rc.Category == service.DigiflazzRCSuccess {
+		textB.WriteString(fmt.Sprintf("\nRef ID: <code>%s</code>", refId))
+	} else if !customer {
+		textB.WriteString(fmt.Sprintf("\n\n<i>%s</i>", rc.Note()))
+	}
+	if digiflazzRes.Data.Status == types.DigiflazzTrxStatusFailed {
+		refund, err := refundText(ctx, refId)
 		if err != nil {
-			return nil, util.NewError(err)
+			return nil, err
 		}
-		_, err = repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
-			ID:      chatId,
-			Command: trxCmd,
-			Step:    2,
-			Data:    trxDataB,
-		})
-		if err != nil {
-			return nil, util.NewError(err)
+		textB.WriteString(refund)
+	}
+
+	return &trxResult{
+		Text:  textB.String(),
+		RefID: refId,
+		RC:    rc,
+	}, nil
+}
+
+// formatPreviousTransaction describes a previous transaction for the duplicate warning.
+func formatPreviousTransaction(trx *database.Transaction) string {
+	var textB strings.Builder
+	textB.WriteString(fmt.Sprintf("Waktu: %s\n", trx.CreatedAt.Time.Format("2 Jan 2006 15:04:05 MST")))
+	textB.WriteString(fmt.Sprintf("Status: %s\n", trx.Status))
+	if trx.Sn != nil && *trx.Sn != "" {
+		if token, ok := types.ParseDigiflazzPLNToken(*trx.Sn); ok {
+			textB.WriteString(fmt.Sprintf("Token: <code>%s</code>\n", receipt.FormatToken(token.Token)))
+		} else {
+			textB.WriteString(fmt.Sprintf("SN: <code>%s</code>\n", *trx.Sn))
 		}
+	}
+	if trx.Message != nil && *trx.Message != "" {
+		textB.WriteString(fmt.Sprintf("Keterangan: %s\n", *trx.Message))
+	}
+	textB.WriteString(fmt.Sprintf("Ref ID: <code>%s</code>\n", trx.RefID))
+	return textB.String()
+}
+
+// confirmTransaction shows the product and asks to confirm buying it for the number.
+func confirmTransaction(ctx context.Context, c *bot.Conv[trxData], productCode, destinationNumber string) (*types.TelegramResponse, error) {
+	// Prepaid product exist?
+	prepaidProduct, err := database.Sqlc.GetPrepaidProductBySKUCode(ctx, productCode)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, util.NewError(err)
+	}
+	if prepaidProduct.ID == 0 {
+		c.End()
 
 		return &types.TelegramResponse{
-			Method:    types.TelegramMethodSendMessage,
-			ChatId:    req.Message.Chat.Id,
-			ParseMode: types.TelegramParseModeHTML,
-			Text:      textB.String(),
-			ReplyMarkup: types.TelegramReplyKeyboardMarkup{
-				ResizeKeyboard: true,
-				Keyboard: [][]string{
-					{"Ya", "Tidak"},
-				},
-			},
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      c.ChatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        "<i>Produk tidak ditemukan</i>",
+			ReplyMarkup: types.DefaultReplyMarkup,
 		}, nil
+	}
 
-	// Step 2
-	case 2:
-		// Delete step
-		defer func() {
-			err = repository.TelegramDeleteChat(ctx, chatId)
-			if err != nil {
-				log.Println(err)
-			}
-		}()
+	// Is account locked?
+	userPin, err := database.Sqlc.GetUserPin(ctx, c.ChatId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, util.NewError(err)
+	}
+	if text := pinLockedText(userPin); text != "" {
+		c.End()
+
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      c.ChatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: types.DefaultReplyMarkup,
+		}, nil
+	}
+
+	// Is it in cut-off or out of stock?
+	unavailableReason := productAvailability(
+		prepaidProduct.UnlimitedStock,
+		prepaidProduct.Stock,
+		prepaidProduct.StartCutOff,
+		prepaidProduct.EndCutOff,
+		time.Now(),
+	)
+	// Without failover, Digiflazz would only reject it
+	if unavailableReason != "" && config.Cfg.TrxFailoverMaxAttempts == 0 {
+		c.End()
+
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      c.ChatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        fmt.Sprintf("<i>%s tidak bisa dibeli saat ini. %s</i>", prepaidProduct.BuyerSkuCode, unavailableReason),
+			ReplyMarkup: types.DefaultReplyMarkup,
+		}, nil
+	}
+
+	// Customers pay the selling price from their wallet
+	customer, err := isCustomer(ctx, c.ChatId)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+	var walletBalance int64
+	if customer {
+		walletBalance, err = database.Sqlc.GetWalletBalance(ctx, c.ChatId)
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+		if walletBalance < sellingPrice(prepaidProduct.Price) {
+			c.End()
 
-		if req.Message.Text != "Ya" {
 			return &types.TelegramResponse{
-				Method:      types.TelegramMethodSendMessage,
-				ChatId:      req.Message.Chat.Id,
-				ParseMode:   types.TelegramParseModeHTML,
-				Text:        "<i>Dibatalkan</i>",
+				Method:    types.TelegramMethodSendMessage,
+				ChatId:    c.ChatId,
+				ParseMode: types.TelegramParseModeHTML,
+				Text: util.Sprintf(
+					"<i>Saldo dompet tidak cukup. Harga Rp %d, saldo Rp %d.</i>\nIsi saldo dengan <code>topup nominal</code>",
+					sellingPrice(prepaidProduct.Price),
+					walletBalance,
+				),
 				ReplyMarkup: types.DefaultReplyMarkup,
 			}, nil
 		}
+	}
 
-		trxData := &trxData{}
-		err := json.Unmarshal(chat.Data, trxData)
-		if err != nil {
+	var prepaidProductStatus string
+	if prepaidProduct.BuyerProductStatus && prepaidProduct.SellerProductStatus {
+		prepaidProductStatus = "✅"
+	} else {
+		prepaidProductStatus = "❌"
+	}
+
+	var textB strings.Builder
+	textB.WriteString(fmt.Sprintf("Kode: %s\n", prepaidProduct.BuyerSkuCode))
+	textB.WriteString(fmt.Sprintf("Tujuan: %s\n", destinationNumber))
+	if customer {
+		textB.WriteString(util.Sprintf("Harga: Rp %d\n", sellingPrice(prepaidProduct.Price)))
+		textB.WriteString(util.Sprintf("Saldo dompet: Rp %d\n\n", walletBalance))
+	} else {
+		textB.WriteString(util.Sprintf("Harga: Rp %d\n\n", prepaidProduct.Price))
+		textB.WriteString(fmt.Sprintf("Seller: %s\n", prepaidProduct.SellerName))
+	}
+	textB.WriteString(fmt.Sprintf("Status: %s\n", prepaidProductStatus))
+	textB.WriteString(fmt.Sprintf("Nama: %s\n", prepaidProduct.Name))
+	textB.WriteString(fmt.Sprintf("Deskripsi: %s\n", *prepaidProduct.Description))
+	if window := cutOffWindow(prepaidProduct.StartCutOff, prepaidProduct.EndCutOff); window != "" {
+		textB.WriteString(fmt.Sprintf("Cut off: %s\n", window))
+	}
+	if unavailableReason != "" {
+		textB.WriteString(fmt.Sprintf("\n⚠️ <b>%s</b>\n", unavailableReason))
+		textB.WriteString("<i>Transaksi akan dialihkan ke seller lain jika gagal</i>\n")
+	}
+
+	trxData := &trxData{
+		Code:   productCode,
+		Number: destinationNumber,
+		Price:  prepaidProduct.Price,
+	}
+
+	// Check duplicate
+	if config.Cfg.DuplicateTrxWindow > 0 {
+		previousTrx, err := database.Sqlc.GetLatestTransactionBySKUAndCustomerNo(ctx, &database.GetLatestTransactionBySKUAndCustomerNoParams{
+			BuyerSkuCode: productCode,
+			CustomerNo:   destinationNumber,
+			CreatedAt:    sql.NullTime{Time: time.Now().Add(-config.Cfg.DuplicateTrxWindow), Valid: true},
+		})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
 			return nil, util.NewError(err)
 		}
+		if previousTrx.ID != 0 {
+			trxData.Duplicate = true
+			textB.WriteString("\n⚠️ <b>Transaksi yang sama sudah pernah dikirim</b>\n")
+			textB.WriteString(formatPreviousTransaction(previousTrx))
+		}
+	}
 
-		// Send to digiflazz
-		digiflazzRes, err := service.DigiflazzCreateTrx(ctx, &service.DigiflazzCreateTrxParams{
-			RefID:        uuid.Must(uuid.NewV7()).String(),
-			BuyerSKUCode: trxData.Code,
-			CustomerNo:   trxData.Number,
-		})
+	// Check limits
+	limitReason, err := checkTrxLimits(ctx, c.ChatId, prepaidProduct.Price)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+	if limitReason != "" {
+		// Without admins, there is no one to approve
+		adminIds, err := bot.ChatsWithRole(ctx, bot.RoleAdmin)
 		if err != nil {
-			var digiflazzError *service.DigiflazzErrorResponse
-			if errors.As(err, &digiflazzError) {
-				return &types.TelegramResponse{
-					Method:      types.TelegramMethodSendMessage,
-					ChatId:      req.Message.Chat.Id,
-					ParseMode:   types.TelegramParseModeHTML,
-					Text:        digiflazzError.Error(),
-					ReplyMarkup: types.DefaultReplyMarkup,
-				}, nil
-			}
 			return nil, util.NewError(err)
 		}
+		if len(adminIds) == 0 {
+			c.End()
 
-		if digiflazzRes.Data.RC == "03" || digiflazzRes.Data.RC == "99" {
 			return &types.TelegramResponse{
 				Method:      types.TelegramMethodSendMessage,
-				ChatId:      req.Message.Chat.Id,
+				ChatId:      c.ChatId,
 				ParseMode:   types.TelegramParseModeHTML,
-				Text:        fmt.Sprintf("<i>%s ke %s sedang diproses...</i>", trxData.Code, trxData.Number),
+				Text:        fmt.Sprintf("<i>Transaksi ditolak. %s</i>", limitReason),
 				ReplyMarkup: types.DefaultReplyMarkup,
 			}, nil
 		}
 
-		var textB strings.Builder
-		textB.WriteString(fmt.Sprintf(
-			"%s ke %s %s. SN: <code>%s</code>. ",
-			digiflazzRes.Data.BuyerSKUCode,
-			digiflazzRes.Data.CustomerNo,
-			digiflazzRes.Data.Status,
-			*digiflazzRes.Data.SN,
-		))
-		textB.WriteString(fmt.Sprintf("Waktu: %s. ", time.Now().Format("2 Jan 2006 15:04:05 MST")))
-		textB.WriteString(fmt.Sprintf("Keterangan: %s", digiflazzRes.Data.Message))
+		textB.WriteString(fmt.Sprintf("\n⚠️ <i>%s</i>\n", limitReason))
+		textB.WriteString("\nMinta persetujuan admin?")
+
+		// Set state
+		trxData.Reason = limitReason
+		c.Data = *trxData
+		c.Goto("approval")
 
 		return &types.TelegramResponse{
-			Method:      types.TelegramMethodSendMessage,
-			ChatId:      req.Message.Chat.Id,
-			ParseMode:   types.TelegramParseModeHTML,
-			Text:        textB.String(),
-			ReplyMarkup: types.DefaultReplyMarkup,
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    c.ChatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      textB.String(),
+			ReplyMarkup: types.TelegramInlineKeyboardMarkup{
+				InlineKeyboard: [][]types.TelegramInlineKeyboardButton{
+					{
+						{Text: "🔓 Minta persetujuan", CallbackData: "request_approval"},
+					},
+					{
+						{Text: "❌", CallbackData: "cancel"},
+					},
+				},
+			},
 		}, nil
+	}
 
-	// Unhandled step
-	default:
-		// Delete step
-		err := repository.TelegramDeleteChat(ctx, chatId)
-		if err != nil {
-			return nil, util.NewError(err)
+	confirmText := trxConfirmText
+	if trxData.Duplicate {
+		confirmText = trxResendText
+		textB.WriteString(fmt.Sprintf("\nTetap kirim? Pilih \"%s\" untuk mengirim transaksi baru.", trxResendText))
+	} else {
+		textB.WriteString("\nYakin ingin memproses?")
+	}
+
+	// Set state
+	c.Data = *trxData
+	c.Goto("confirm")
+
+	return &types.TelegramResponse{
+		Method:    types.TelegramMethodSendMessage,
+		ChatId:    c.ChatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      textB.String(),
+		ReplyMarkup: types.TelegramReplyKeyboardMarkup{
+			ResizeKeyboard: true,
+			Keyboard: [][]string{
+				{confirmText, trxCancelText},
+			},
+		},
+	}, nil
+}
+
+// startTransaction starts the transaction conversation for a product picked
+// from the list, so only the destination number is asked.
+func startTransaction(ctx context.Context, req *types.TelegramUpdate, chatId int64, productCode string) (*types.TelegramResponse, error) {
+	return transactionConversation.Start(ctx, req, chatId, "number", trxData{
+		Code: productCode,
+	})
+}
+
+type CreateTransactionParams struct {
+	Code   string
+	Number string
+	// AllowDuplicate sends it even if the same product was sent to the same number recently
+	AllowDuplicate bool
+}
+
+// CreateTransaction sends a transaction for the REST API, with failover. The
+// product is checked like in a chat, but limits and PINs only apply to chats.
+// It returns the ref ID of the last attempt.
+func CreateTransaction(ctx context.Context, arg *CreateTransactionParams) (string, error) {
+	number := normalizeTrxNumber(arg.Number)
+	if !trxNumberPattern.MatchString(number) {
+		return "", ErrInvalidNumber
+	}
+
+	prepaidProduct, err := database.Sqlc.GetPrepaidProductBySKUCode(ctx, arg.Code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrProductNotFound
 		}
+		return "", err
+	}
 
-		return &types.TelegramResponse{
-			Method:      types.TelegramMethodSendMessage,
-			ChatId:      req.Message.Chat.Id,
-			ParseMode:   types.TelegramParseModeHTML,
-			Text:        "<i>Unhandled step</i>",
-			ReplyMarkup: types.DefaultReplyMarkup,
-		}, nil
+	// Without failover, Digiflazz would only reject it
+	unavailableReason := productAvailability(
+		prepaidProduct.UnlimitedStock,
+		prepaidProduct.Stock,
+		prepaidProduct.StartCutOff,
+		prepaidProduct.EndCutOff,
+		time.Now(),
+	)
+	if unavailableReason != "" && config.Cfg.TrxFailoverMaxAttempts == 0 {
+		return "", fmt.Errorf("%w: %s", ErrProductUnavailable, unavailableReason)
 	}
 
+	if !arg.AllowDuplicate && config.Cfg.DuplicateTrxWindow > 0 {
+		previousTrx, err := database.Sqlc.GetLatestTransactionBySKUAndCustomerNo(ctx, &database.GetLatestTransactionBySKUAndCustomerNoParams{
+			BuyerSkuCode: prepaidProduct.BuyerSkuCode,
+			CustomerNo:   number,
+			CreatedAt:    sql.NullTime{Time: time.Now().Add(-config.Cfg.DuplicateTrxWindow), Valid: true},
+		})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return "", err
+		}
+		if previousTrx.ID != 0 {
+			return "", fmt.Errorf("%w: %s", ErrDuplicateTransaction, previousTrx.RefID)
+		}
+	}
+
+	result, err := createTransaction(ctx, repository.APIChatID, &trxData{
+		Code:   prepaidProduct.BuyerSkuCode,
+		Number: number,
+		Price:  prepaidProduct.Price,
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.RefID, nil
 }
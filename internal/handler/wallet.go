@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/database/repository"
+	"github.com/fidrasofyan/digiflazz-bot/internal/bot"
+	"github.com/fidrasofyan/digiflazz-bot/internal/channel"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/receipt"
+	"github.com/fidrasofyan/digiflazz-bot/internal/service"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+// TopupCallbackPrefix prefixes the callback data of the admin top-up buttons.
+// Format: _topup:<approve|reject>:<topup_id>
+const TopupCallbackPrefix = "_topup:"
+
+var walletEntryLabels = map[string]string{
+	repository.WalletEntryTopup:    "Top up",
+	repository.WalletEntryPurchase: "Pembelian",
+	repository.WalletEntryRefund:   "Pengembalian",
+}
+
+// sellingPrice is the price customers pay for a product, see SELLING_PRICE_MARKUP.
+func sellingPrice(price int64) int64 {
+	return price + config.Cfg.SellingPriceMarkup
+}
+
+// isCustomer reports whether the chat pays from its wallet.
+func isCustomer(ctx context.Context, chatId int64) (bool, error) {
+	role, err := bot.RoleOf(ctx, chatId)
+	if err != nil {
+		return false, err
+	}
+	return role == bot.RoleCustomer, nil
+}
+
+// chargedPrice is the amount a chat pays for a product with the given base price.
+func chargedPrice(ctx context.Context, chatId int64, price int64) (int64, error) {
+	customer, err := isCustomer(ctx, chatId)
+	if err != nil {
+		return 0, err
+	}
+	if customer {
+		return sellingPrice(price), nil
+	}
+	return price, nil
+}
+
+// refundText refunds the wallet of a failed transaction, if it was paid from one,
+// and returns the note to add to the transaction message.
+func refundText(ctx context.Context, refId string) (string, error) {
+	entry, err := repository.RefundWalletPurchase(ctx, refId)
+	if err != nil || entry == nil {
+		return "", err
+	}
+	log.Printf("Transaction %s refunded: Rp %d to wallet %d", refId, entry.Amount, entry.ChatID)
+	return receipt.RefundText(ctx, entry)
+}
+
+// Wallet shows the wallet balance and the latest entries of a customer.
+func Wallet(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.Message.Chat.Id
+
+	balance, err := database.Sqlc.GetWalletBalance(ctx, chatId)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+	entries, err := database.Sqlc.ListWalletEntries(ctx, &database.ListWalletEntriesParams{
+		ChatID: chatId,
+		Limit:  10,
+	})
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+
+	var textB strings.Builder
+	textB.WriteString(util.Sprintf("<b>Saldo dompet: Rp %d</b>\n", balance))
+	if len(entries) == 0 {
+		textB.WriteString("\n<i>Belum ada riwayat. Isi saldo dengan</i> <code>topup nominal</code>")
+	} else {
+		textB.WriteString("\nRiwayat terakhir:\n")
+	}
+	for _, entry := range entries {
+		textB.WriteString(util.Sprintf(
+			"%s  %+d  %s",
+			entry.CreatedAt.Time.Format("2 Jan 15:04"),
+			entry.Amount,
+			walletEntryLabels[entry.Kind],
+		))
+		if entry.Kind != repository.WalletEntryTopup {
+			textB.WriteString(fmt.Sprintf(" <code>%s</code>", entry.RefID))
+		}
+		textB.WriteString("\n")
+	}
+
+	return &types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      chatId,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        textB.String(),
+		ReplyMarkup: types.DefaultReplyMarkup,
+	}, nil
+}
+
+// Topup asks the admins to approve a wallet top-up.
+// "topup nominal"
+func Topup(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.Message.Chat.Id
+	from := &req.Message.From
+
+	amount, err := strconv.ParseInt(strings.Fields(req.Message.Text)[1], 10, 64)
+	if err != nil || amount < max(config.Cfg.WalletTopupMin, 1) {
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        util.Sprintf("<i>Minimal top up Rp %d</i>", max(config.Cfg.WalletTopupMin, 1)),
+			ReplyMarkup: types.DefaultReplyMarkup,
+		}, nil
+	}
+
+	// Without admins, there is no one to approve
+	adminIds, err := bot.ChatsWithRole(ctx, bot.RoleAdmin)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+	if len(adminIds) == 0 {
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        "<i>Top up sedang tidak tersedia</i>",
+			ReplyMarkup: types.DefaultReplyMarkup,
+		}, nil
+	}
+
+	topup, err := database.Sqlc.CreateWalletTopup(ctx, &database.CreateWalletTopupParams{
+		ChatID:    chatId,
+		Amount:    amount,
+		Status:    repository.WalletTopupPending,
+		CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+
+	var adminTextB strings.Builder
+	adminTextB.WriteString("<b>Permintaan top up</b>\n\n")
+	adminTextB.WriteString(fmt.Sprintf(
+		"Dari: %s (<code>%d</code>)\n",
+		html.EscapeString(strings.TrimSpace(from.FirstName+" "+from.LastName)),
+		chatId,
+	))
+	adminTextB.WriteString(util.Sprintf("Nominal: Rp %d", amount))
+
+	for _, adminId := range adminIds {
+		err := channel.Send(ctx, adminId, &channel.Message{
+			Text: adminTextB.String(),
+			Buttons: [][]channel.Button{
+				{
+					{Text: "✅ Setujui", Data: fmt.Sprintf("%sapprove:%d", TopupCallbackPrefix, topup.ID)},
+					{Text: "❌ Tolak", Data: fmt.Sprintf("%sreject:%d", TopupCallbackPrefix, topup.ID)},
+				},
+			},
+		})
+		if err != nil {
+			log.Printf("Error sending message: %v", err)
+		}
+	}
+
+	text := util.Sprintf("<i>Permintaan top up Rp %d menunggu persetujuan admin...</i>", amount)
+	if config.Cfg.WalletTopupInstructions != "" {
+		text += "\n\n" + html.EscapeString(config.Cfg.WalletTopupInstructions)
+	}
+
+	return &types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      chatId,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        text,
+		ReplyMarkup: types.DefaultReplyMarkup,
+	}, nil
+}
+
+// TopupDecision handles the admin decision on a top-up request.
+func TopupDecision(ctx context.Context, req *types.TelegramUpdate) (*types.TelegramResponse, error) {
+	// Answer callback query
+	go func() {
+		acqCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		service.Telegram.AnswerCallbackQuery(acqCtx, &service.TelegramAnswerCallbackQueryParams{
+			CallbackQueryId: req.CallbackQuery.Id,
+		})
+	}()
+
+	adminId := req.CallbackQuery.From.Id
+
+	data := strings.Split(strings.TrimPrefix(req.CallbackQuery.Data, TopupCallbackPrefix), ":")
+	if len(data) != 2 {
+		return nil, util.NewError(fmt.Errorf("invalid top up callback data: %s", req.CallbackQuery.Data))
+	}
+	topupId, err := strconv.ParseInt(data[1], 10, 64)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+
+	status := repository.WalletTopupRejected
+	if data[0] == "approve" {
+		status = repository.WalletTopupApproved
+	}
+
+	// Decide atomically, so a top-up can only be credited once
+	topup, err := repository.DecideWalletTopup(ctx, topupId, status, adminId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &types.TelegramResponse{
+				Method:    types.TelegramMethodEditMessageText,
+				MessageId: req.CallbackQuery.Message.MessageId,
+				ChatId:    req.CallbackQuery.Message.Chat.Id,
+				ParseMode: types.TelegramParseModeHTML,
+				Text:      html.EscapeString(req.CallbackQuery.Message.Text) + "\n\n<i>Permintaan sudah diproses</i>",
+			}, nil
+		}
+		return nil, util.NewError(err)
+	}
+
+	var resultText string
+	if topup.Status == repository.WalletTopupApproved {
+		balance, err := database.Sqlc.GetWalletBalance(ctx, topup.ChatID)
+		if err != nil {
+			return nil, util.NewError(err)
+		}
+		resultText = util.Sprintf("✅ Top up Rp %d disetujui. Saldo dompet: Rp %d", topup.Amount, balance)
+	} else {
+		resultText = util.Sprintf("<i>Top up Rp %d ditolak admin</i>", topup.Amount)
+	}
+
+	// Notify customer
+	err = channel.Send(ctx, topup.ChatID, &channel.Message{
+		Text: resultText,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+
+	return &types.TelegramResponse{
+		Method:    types.TelegramMethodEditMessageText,
+		MessageId: req.CallbackQuery.Message.MessageId,
+		ChatId:    req.CallbackQuery.Message.Chat.Id,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      html.EscapeString(req.CallbackQuery.Message.Text) + "\n\n" + resultText,
+	}, nil
+}
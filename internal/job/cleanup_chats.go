@@ -0,0 +1,43 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/bot"
+	"github.com/fidrasofyan/digiflazz-bot/internal/channel"
+	"github.com/fidrasofyan/digiflazz-bot/internal/service"
+)
+
+// CleanupExpiredChats ends idle conversations and tells their users.
+func CleanupExpiredChats(ctx context.Context) error {
+	chats, err := database.Sqlc.DeleteExpiredChats(ctx, sql.NullTime{
+		Time:  time.Now(),
+		Valid: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, chat := range chats {
+		err := channel.Send(ctx, chat.ID, &channel.Message{
+			Text: bot.SessionExpiredText,
+			Menu: true,
+		})
+		if errors.Is(err, service.ErrTelegramForbidden) {
+			log.Printf("Chat %d blocked the bot", chat.ID)
+			continue
+		}
+		if err != nil {
+			log.Printf("Error sending message: %v", err)
+		}
+	}
+	if len(chats) > 0 {
+		log.Printf("CleanupExpiredChats: %d conversations expired", len(chats))
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+)
+
+// CleanupTelegramUpdates deletes processed update IDs older than the TTL.
+func CleanupTelegramUpdates(ctx context.Context) error {
+	deleted, err := database.Sqlc.DeleteTelegramUpdatesBefore(ctx, sql.NullTime{
+		Time:  time.Now().Add(-config.Cfg.TelegramUpdateTTL),
+		Valid: true,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("CleanupTelegramUpdates: %d updates deleted", deleted)
+	}
+	return nil
+}
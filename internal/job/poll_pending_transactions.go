@@ -0,0 +1,78 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/service"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+)
+
+// PollPendingTransactions checks the status of pending transactions by
+// resending them with the same ref_id, which Digiflazz treats as a status check.
+// It replaces the Digiflazz webhook when the bot isn't publicly reachable, and
+// reconciles transactions whose request to Digiflazz failed without an answer.
+func PollPendingTransactions(ctx context.Context) error {
+	now := time.Now()
+	transactions, err := database.Sqlc.ListPendingTransactions(ctx, &database.ListPendingTransactionsParams{
+		CreatedAfter:  sql.NullTime{Time: now.Add(-24 * time.Hour), Valid: true},
+		UpdatedBefore: sql.NullTime{Time: now.Add(-service.DefaultDigiflazzTimeouts.Transaction), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, trx := range transactions {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		res, err := service.Digiflazz.CreateTrx(ctx, &service.DigiflazzCreateTrxParams{
+			RefID:        trx.RefID,
+			BuyerSKUCode: trx.BuyerSkuCode,
+			CustomerNo:   trx.CustomerNo,
+		})
+		if err != nil {
+			var digiflazzError *service.DigiflazzError
+			if !errors.As(err, &digiflazzError) || !digiflazzError.Rejected() {
+				log.Printf("PollPendingTransactions: %s: %v", trx.RefID, err)
+				continue
+			}
+			res = &service.DigiflazzCreateTrxResponse{
+				Data: service.DigiflazzTrxData{
+					RefID:        trx.RefID,
+					CustomerNo:   trx.CustomerNo,
+					BuyerSKUCode: trx.BuyerSkuCode,
+					Message:      digiflazzError.Message,
+					Status:       types.DigiflazzTrxStatusFailed,
+					RC:           digiflazzError.RC,
+					Price:        int32(trx.Price),
+				},
+			}
+		}
+
+		if res.Data.Status == types.DigiflazzTrxStatusPending {
+			continue
+		}
+
+		ApplyTransactionUpdate(ctx, &types.DigiflazzUpdateData{
+			RefID:          res.Data.RefID,
+			CustomerNo:     res.Data.CustomerNo,
+			BuyerSKUCode:   res.Data.BuyerSKUCode,
+			Message:        res.Data.Message,
+			Status:         string(res.Data.Status),
+			RC:             res.Data.RC,
+			BuyerLastSaldo: res.Data.BuyerLastSaldo,
+			SN:             res.Data.SN,
+			Price:          res.Data.Price,
+		})
+	}
+
+	return nil
+}
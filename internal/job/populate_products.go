@@ -11,7 +11,7 @@ import (
 func PopulateProducts(ctx context.Context) error {
 	// Get prepaid products from Digiflazz
 	log.Println("PopulateProducts: fetching prepaid products...")
-	res, err := service.DigiflazzGetPrepaidPriceList(ctx)
+	res, err := service.Digiflazz.GetPrepaidPriceList(ctx)
 	if err != nil {
 		return err
 	}
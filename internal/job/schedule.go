@@ -0,0 +1,27 @@
+package job
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunPeriodically runs the job every interval until the context is done.
+// Errors are logged, they don't stop the schedule.
+func RunPeriodically(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := fn(ctx); err != nil {
+					log.Printf("%s: %v", name, err)
+				}
+			}
+		}
+	}()
+}
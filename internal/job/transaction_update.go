@@ -0,0 +1,138 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/database/repository"
+	"github.com/fidrasofyan/digiflazz-bot/internal/bot"
+	"github.com/fidrasofyan/digiflazz-bot/internal/channel"
+	"github.com/fidrasofyan/digiflazz-bot/internal/receipt"
+	"github.com/fidrasofyan/digiflazz-bot/internal/service"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+// ApplyTransactionUpdate stores a transaction status update, from the
+// Digiflazz webhook or the status poller, and notifies the allowed users.
+func ApplyTransactionUpdate(ctx context.Context, data *types.DigiflazzUpdateData) {
+	// The chat was already told, and sent the receipt, if it didn't wait
+	trx, err := database.Sqlc.GetTransactionByRefID(ctx, data.RefID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error getting transaction: %v", err)
+	}
+	wasPending := trx != nil && trx.ID != 0 && trx.Status == string(types.DigiflazzTrxStatusPending)
+	// Transactions made with the API have no chat to tell
+	hasChat := trx != nil && trx.ID != 0 && trx.ChatID != repository.APIChatID
+
+	// Update transaction
+	err = repository.UpdateTransaction(ctx, &repository.UpdateTransactionParams{
+		RefID:   data.RefID,
+		Price:   int64(data.Price),
+		Status:  data.Status,
+		RC:      data.RC,
+		SN:      data.SN,
+		Message: data.Message,
+	})
+	if err != nil {
+		log.Printf("Error updating transaction: %v", err)
+	}
+
+	rc := service.LookupDigiflazzRC(data.RC)
+	log.Printf("Transaction %s %s: %s", data.RefID, data.Status, rc)
+
+	// Refund the wallet of a customer, also when a success is reversed later
+	var refund *database.WalletEntry
+	if data.Status == string(types.DigiflazzTrxStatusFailed) {
+		refund, err = repository.RefundWalletPurchase(ctx, data.RefID)
+		if err != nil {
+			log.Printf("Error refunding transaction %s: %v", data.RefID, err)
+		}
+		if refund != nil {
+			log.Printf("Transaction %s refunded: Rp %d to wallet %d", data.RefID, refund.Amount, refund.ChatID)
+		}
+	}
+
+	var sn string
+	if data.SN != nil {
+		sn = *data.SN
+	}
+
+	var textB strings.Builder
+	textB.WriteString(fmt.Sprintf(
+		"%s ke %s %s.",
+		data.BuyerSKUCode,
+		data.CustomerNo,
+		data.Status,
+	))
+	textB.WriteString(receipt.SNText(sn))
+	textB.WriteString(util.Sprintf("Harga: %d. Saldo: %d. ", data.Price, data.BuyerLastSaldo))
+	textB.WriteString(fmt.Sprintf("Waktu: %s. ", time.Now().Format("2 Jan 2006 15:04:05 MST")))
+	textB.WriteString(fmt.Sprintf("Keterangan: %s", data.Message))
+	if rc.Category != service.DigiflazzRCSuccess {
+		textB.WriteString(fmt.Sprintf("\n\n<i>%s</i>", rc.Note()))
+	}
+	if refund != nil {
+		textB.WriteString(util.Sprintf("\n\n<i>Dana Rp %d dikembalikan ke dompet</i> ", refund.Amount))
+		textB.WriteString(fmt.Sprintf("<code>%d</code>", refund.ChatID))
+	}
+
+	chatIds, err := bot.ChatsWithRole(ctx, bot.RoleUser)
+	if err != nil {
+		log.Printf("Error getting chats: %v", err)
+		return
+	}
+	for _, chatId := range chatIds {
+		err := channel.Send(ctx, chatId, &channel.Message{
+			Text: textB.String(),
+		})
+		if errors.Is(err, service.ErrTelegramForbidden) {
+			log.Printf("Chat %d blocked the bot", chatId)
+			continue
+		}
+		if err != nil {
+			log.Printf("Error sending message: %v", err)
+		}
+	}
+
+	// Customers don't get the broadcast, tell them about their own transaction
+	if hasChat && (wasPending || refund != nil) && !slices.Contains(chatIds, trx.ChatID) {
+		var customerTextB strings.Builder
+		customerTextB.WriteString(fmt.Sprintf(
+			"%s ke %s %s.",
+			data.BuyerSKUCode,
+			data.CustomerNo,
+			data.Status,
+		))
+		customerTextB.WriteString(receipt.SNText(sn))
+		customerTextB.WriteString(fmt.Sprintf("Keterangan: %s", data.Message))
+		if refund != nil {
+			refundText, err := receipt.RefundText(ctx, refund)
+			if err != nil {
+				log.Printf("Error getting wallet balance: %v", err)
+			}
+			customerTextB.WriteString(refundText)
+		}
+
+		err := channel.Send(ctx, trx.ChatID, &channel.Message{
+			Text: customerTextB.String(),
+		})
+		if err != nil {
+			log.Printf("Error sending message: %v", err)
+		}
+	}
+
+	// Send the receipt to the chat that made the transaction
+	if hasChat && wasPending && rc.Category == service.DigiflazzRCSuccess {
+		if err := receipt.AutoSend(ctx, trx.ChatID, trx.RefID); err != nil {
+			log.Printf("Error sending receipt %s: %v", trx.RefID, err)
+		}
+	}
+}
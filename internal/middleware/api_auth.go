@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/database/repository"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+const apiKeyLocal = "apiKey"
+
+// APIAuth authenticates REST API requests with "Authorization: Bearer <key>".
+func APIAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, ok := strings.CutPrefix(c.Get("Authorization"), "Bearer ")
+		if !ok || key == "" {
+			return fiber.NewError(401, "Unauthorized")
+		}
+
+		apiKey, err := repository.AuthenticateAPIKey(c.UserContext(), key)
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return fiber.NewError(401, "Unauthorized")
+		}
+		if err != nil {
+			return util.NewError(err)
+		}
+
+		c.Locals(apiKeyLocal, apiKey)
+		return c.Next()
+	}
+}
+
+// APIScope only lets keys granted the scope through. It must come after APIAuth.
+func APIScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !repository.APIKeyHasScope(APIKeyOf(c), scope) {
+			return fiber.NewError(403, "Missing scope "+scope)
+		}
+		return c.Next()
+	}
+}
+
+// APIKeyOf returns the key that authenticated the request.
+func APIKeyOf(c *fiber.Ctx) *database.ApiKey {
+	return c.Locals(apiKeyLocal).(*database.ApiKey)
+}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/bot"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Conversation replies that call Digiflazz. Commands tell it with Expensive.
+var expensiveReplies = []string{"ya", "kirim ulang"}
+
+type tokenBucket struct {
+	tokens   float64
+	lastFill time.Time
+}
+
+type offender struct {
+	throttled int
+	notified  bool
+	lastSeen  time.Time
+}
+
+// TelegramRateLimiter is a per-chat token-bucket rate limiter with a stricter
+// bucket for expensive commands. It is safe for concurrent use.
+type TelegramRateLimiter struct {
+	mu          sync.Mutex
+	router      *bot.Router
+	perMinute   float64
+	expensive   float64
+	buckets     map[int64]*tokenBucket
+	expBuckets  map[int64]*tokenBucket
+	offenders   map[int64]*offender
+	lastCleanup time.Time
+}
+
+// NewTelegramRateLimiter returns a limiter that looks up the commands of
+// messages in the router to tell the expensive ones.
+func NewTelegramRateLimiter(router *bot.Router, perMinute, expensivePerMinute int64) *TelegramRateLimiter {
+	return &TelegramRateLimiter{
+		router:      router,
+		perMinute:   float64(perMinute),
+		expensive:   float64(expensivePerMinute),
+		buckets:     make(map[int64]*tokenBucket),
+		expBuckets:  make(map[int64]*tokenBucket),
+		offenders:   make(map[int64]*offender),
+		lastCleanup: time.Now(),
+	}
+}
+
+// refill refills the bucket (capacity = perMinute, refilled over a minute) and
+// returns it. It returns nil if the bucket is unlimited.
+func refill(buckets map[int64]*tokenBucket, chatId int64, perMinute float64, now time.Time) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+
+	bucket, ok := buckets[chatId]
+	if !ok {
+		bucket = &tokenBucket{tokens: perMinute, lastFill: now}
+		buckets[chatId] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.lastFill).Minutes() * perMinute
+	if bucket.tokens > perMinute {
+		bucket.tokens = perMinute
+	}
+	bucket.lastFill = now
+	return bucket
+}
+
+// hasToken reports whether a token can be taken from the bucket.
+func (b *tokenBucket) hasToken() bool {
+	return b == nil || b.tokens >= 1
+}
+
+// takeToken takes one token from the bucket.
+func (b *tokenBucket) takeToken() {
+	if b != nil {
+		b.tokens--
+	}
+}
+
+// Allow reports whether the chat may proceed. When it may not, notify is true
+// only for the first throttled update in a row, so the user isn't spammed.
+func (l *TelegramRateLimiter) Allow(chatId int64, expensive bool) (allowed bool, notify bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.cleanup(now)
+
+	// Take from the buckets only if all of them allow it, so a throttled
+	// expensive command doesn't use up the general allowance
+	bucket := refill(l.buckets, chatId, l.perMinute, now)
+	var expBucket *tokenBucket
+	if expensive {
+		expBucket = refill(l.expBuckets, chatId, l.expensive, now)
+	}
+	allowed = bucket.hasToken() && expBucket.hasToken()
+	if allowed {
+		bucket.takeToken()
+		expBucket.takeToken()
+	}
+
+	if allowed {
+		if o, ok := l.offenders[chatId]; ok {
+			o.notified = false
+		}
+		return true, false
+	}
+
+	o, ok := l.offenders[chatId]
+	if !ok {
+		o = &offender{}
+		l.offenders[chatId] = o
+	}
+	o.throttled++
+	o.lastSeen = now
+
+	// Log repeat offenders
+	if o.throttled == 1 || o.throttled%10 == 0 {
+		log.Printf("Rate limit: chat %d throttled %d times", chatId, o.throttled)
+	}
+
+	notify = !o.notified
+	o.notified = true
+	return false, notify
+}
+
+// cleanup forgets idle chats. Must be called with the lock held.
+func (l *TelegramRateLimiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < 10*time.Minute {
+		return
+	}
+	l.lastCleanup = now
+
+	for chatId, bucket := range l.buckets {
+		if now.Sub(bucket.lastFill) > 10*time.Minute {
+			delete(l.buckets, chatId)
+		}
+	}
+	for chatId, bucket := range l.expBuckets {
+		if now.Sub(bucket.lastFill) > 10*time.Minute {
+			delete(l.expBuckets, chatId)
+		}
+	}
+	for chatId, o := range l.offenders {
+		if now.Sub(o.lastSeen) > 1*time.Hour {
+			delete(l.offenders, chatId)
+		}
+	}
+}
+
+// Check applies the limiter to an update. If the update is throttled, it
+// returns true and, at most once per streak, the response to send.
+func (l *TelegramRateLimiter) Check(req *types.TelegramUpdate) (*types.TelegramResponse, bool) {
+	var chatId int64
+	var expensive bool
+
+	switch {
+	case req.CallbackQuery != nil:
+		chatId = req.CallbackQuery.From.Id
+	case req.Message != nil:
+		chatId = req.Message.Chat.Id
+		reply := strings.TrimSpace(strings.ToLower(req.Message.Text))
+		expensive = slices.Contains(expensiveReplies, reply)
+		if !expensive {
+			command := l.router.Match(req.Message.Text)
+			expensive = command != nil && command.Expensive
+		}
+	default:
+		return nil, false
+	}
+
+	allowed, notify := l.Allow(chatId, expensive)
+	if allowed {
+		return nil, false
+	}
+	if !notify {
+		return nil, true
+	}
+
+	if req.CallbackQuery != nil {
+		return &types.TelegramResponse{
+			Method:          types.TelegramMethodAnswerCallbackQuery,
+			CallbackQueryId: req.CallbackQuery.Id,
+			Text:            "Tunggu sebentar...",
+		}, true
+	}
+
+	return &types.TelegramResponse{
+		Method:    types.TelegramMethodSendMessage,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      "<i>Terlalu banyak permintaan. Tunggu sebentar...</i>",
+	}, true
+}
+
+func TelegramRateLimit(limiter *TelegramRateLimiter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req types.TelegramUpdate
+		if err := json.Unmarshal(c.Body(), &req); err != nil {
+			return c.Next()
+		}
+
+		resp, limited := limiter.Check(&req)
+		if !limited {
+			return c.Next()
+		}
+		if resp == nil {
+			return c.Status(200).SendString("OK")
+		}
+		return c.Status(200).JSON(resp)
+	}
+}
@@ -0,0 +1,122 @@
+package middleware_test
+
+import (
+	"testing"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/middleware"
+	"github.com/fidrasofyan/digiflazz-bot/internal/route"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+)
+
+type allowCall struct {
+	chatId    int64
+	expensive bool
+	allowed   bool
+	notify    bool
+}
+
+func TestTelegramRateLimiterAllow(t *testing.T) {
+	tests := []struct {
+		name               string
+		perMinute          int64
+		expensivePerMinute int64
+		calls              []allowCall
+	}{
+		{
+			name:      "general allowance",
+			perMinute: 2,
+			calls: []allowCall{
+				{chatId: 1, allowed: true},
+				{chatId: 1, allowed: true},
+				{chatId: 1, allowed: false, notify: true},
+				{chatId: 1, allowed: false, notify: false},
+				{chatId: 2, allowed: true},
+			},
+		},
+		{
+			name:               "expensive allowance",
+			perMinute:          10,
+			expensivePerMinute: 1,
+			calls: []allowCall{
+				{chatId: 1, expensive: true, allowed: true},
+				{chatId: 1, expensive: true, allowed: false, notify: true},
+				{chatId: 1, allowed: true},
+				{chatId: 1, expensive: true, allowed: false, notify: true},
+			},
+		},
+		{
+			name:               "throttled expensive keeps the general allowance",
+			perMinute:          2,
+			expensivePerMinute: 1,
+			calls: []allowCall{
+				{chatId: 1, expensive: true, allowed: true},
+				{chatId: 1, expensive: true, allowed: false, notify: true},
+				{chatId: 1, expensive: true, allowed: false, notify: false},
+				{chatId: 1, allowed: true},
+				{chatId: 1, allowed: false, notify: true},
+			},
+		},
+		{
+			name: "unlimited",
+			calls: []allowCall{
+				{chatId: 1, expensive: true, allowed: true},
+				{chatId: 1, expensive: true, allowed: true},
+				{chatId: 1, allowed: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := middleware.NewTelegramRateLimiter(route.TelegramRouter, tt.perMinute, tt.expensivePerMinute)
+			for i, c := range tt.calls {
+				allowed, notify := limiter.Allow(c.chatId, c.expensive)
+				if allowed != c.allowed || notify != c.notify {
+					t.Errorf("call %d: Allow(%d, %v) = %v, %v, want %v, %v",
+						i, c.chatId, c.expensive, allowed, notify, c.allowed, c.notify)
+				}
+			}
+		})
+	}
+}
+
+func TestTelegramRateLimiterCheckExpensive(t *testing.T) {
+	tests := []struct {
+		text      string
+		expensive bool
+	}{
+		{"cek saldo", true},
+		{"/saldo", true},
+		{"Refresh", true},
+		{"struk 123", true},
+		{"IG100 085808580858", true},
+		{"tsel10 +6281234", true},
+		{"Ya", true},
+		{"kirim ulang", true},
+		{"pin 1234", false},
+		{"limit 12345", false},
+		{"role 12345", false},
+		{"topup 50000", false},
+		{"daftar produk", false},
+		{"halo", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			// Only the expensive allowance is limited, so a second expensive
+			// message is throttled and any other message isn't
+			limiter := middleware.NewTelegramRateLimiter(route.TelegramRouter, 0, 1)
+			req := &types.TelegramUpdate{
+				Message: &types.TelegramMessage{
+					Chat: types.TelegramChat{Id: 1},
+					Text: tt.text,
+				},
+			}
+			limiter.Check(req)
+			_, limited := limiter.Check(req)
+			if limited != tt.expensive {
+				t.Errorf("second %q limited = %v, want %v", tt.text, limited, tt.expensive)
+			}
+		})
+	}
+}
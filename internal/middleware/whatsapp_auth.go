@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// WhatsAppAuth verifies that a webhook was signed with the app secret.
+func WhatsAppAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		signature := c.Get("X-Hub-Signature-256")
+		if signature == "" {
+			return c.Status(401).SendString("Unauthorized")
+		}
+
+		// Verify signature
+		mac := hmac.New(sha256.New, []byte(config.Cfg.WhatsAppAppSecret))
+		mac.Write(c.Body())
+		expectedMAC := mac.Sum(nil)
+		expectedSignature := "sha256=" + hex.EncodeToString(expectedMAC)
+
+		valid := hmac.Equal([]byte(expectedSignature), []byte(signature))
+		if !valid {
+			return c.Status(401).SendString("Unauthorized")
+		}
+
+		return c.Next()
+	}
+}
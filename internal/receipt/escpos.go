@@ -0,0 +1,52 @@
+package receipt
+
+import (
+	"bytes"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ESC/POS commands, supported by practically every thermal printer
+var (
+	escposInit       = []byte{0x1b, '@'}    // ESC @, reset
+	escposCodePage   = []byte{0x1b, 't', 0} // ESC t 0, PC437
+	escposBoldOn     = []byte{0x1b, 'E', 1} // ESC E 1
+	escposBoldOff    = []byte{0x1b, 'E', 0} // ESC E 0
+	escposFeed       = []byte{0x1b, 'd', 4} // ESC d 4, feed 4 lines past the cutter
+	escposPartialCut = []byte{0x1d, 'V', 1} // GS V 1
+)
+
+// ESCPOS renders the receipt as an ESC/POS byte stream for a 58 mm thermal
+// printer, using font A (32 characters per line).
+func ESCPOS(r *Receipt) ([]byte, error) {
+	// Characters missing from the code page are printed as "?"
+	encoder := encoding.ReplaceUnsupported(charmap.CodePage437.NewEncoder())
+
+	var buf bytes.Buffer
+	buf.Write(escposInit)
+	buf.Write(escposCodePage)
+
+	for _, line := range r.Lines() {
+		text, err := encoder.String(line.Text)
+		if err != nil {
+			return nil, err
+		}
+
+		// ESC a n, justification. Align values are the same as n.
+		buf.Write([]byte{0x1b, 'a', byte(line.Align)})
+		if line.Bold {
+			buf.Write(escposBoldOn)
+		}
+		buf.WriteString(text)
+		buf.WriteByte('\n')
+		if line.Bold {
+			buf.Write(escposBoldOff)
+		}
+	}
+
+	buf.Write([]byte{0x1b, 'a', byte(AlignLeft)})
+	buf.Write(escposFeed)
+	buf.Write(escposPartialCut)
+	return buf.Bytes(), nil
+}
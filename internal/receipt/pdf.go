@@ -0,0 +1,57 @@
+package receipt
+
+import (
+	"bytes"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// 80 mm paper with a 58 mm printable area fits on both common printer widths
+const (
+	pdfPageWidth  = 80.0
+	pdfMargin     = 8.0
+	pdfFontSize   = 9.5
+	pdfLineHeight = 4.5
+)
+
+// PDF renders the receipt as a printable PDF, to be sent with sendDocument.
+func PDF(r *Receipt) ([]byte, error) {
+	lines := r.Lines()
+	pageHeight := float64(len(lines))*pdfLineHeight + 2*pdfMargin
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		UnitStr: "mm",
+		Size:    fpdf.SizeType{Wd: pdfPageWidth, Ht: pageHeight},
+	})
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetTitle("Struk "+r.RefID, true)
+	pdf.AddPage()
+
+	// Core fonts are encoded in cp1252
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	textWidth := pdfPageWidth - 2*pdfMargin
+
+	for _, line := range lines {
+		style := ""
+		if line.Bold {
+			style = "B"
+		}
+		pdf.SetFont("Courier", style, pdfFontSize)
+
+		align := "L"
+		switch line.Align {
+		case AlignCenter:
+			align = "C"
+		case AlignRight:
+			align = "R"
+		}
+		pdf.CellFormat(textWidth, pdfLineHeight, tr(line.Text), "", 1, align, false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,100 @@
+package receipt
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	pngFontSize = 24
+	pngPadding  = 32
+)
+
+var (
+	loadFacesOnce sync.Once
+	regularFace   font.Face
+	boldFace      font.Face
+	loadFacesErr  error
+)
+
+func loadFaces() error {
+	loadFacesOnce.Do(func() {
+		regularFace, loadFacesErr = newFace(gomono.TTF)
+		if loadFacesErr != nil {
+			return
+		}
+		boldFace, loadFacesErr = newFace(gomonobold.TTF)
+	})
+	return loadFacesErr
+}
+
+func newFace(ttf []byte) (font.Face, error) {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    pngFontSize,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+}
+
+// PNG renders the receipt as an image, to be sent with sendPhoto.
+func PNG(r *Receipt) ([]byte, error) {
+	if err := loadFaces(); err != nil {
+		return nil, err
+	}
+
+	lines := r.Lines()
+	metrics := regularFace.Metrics()
+	lineHeight := (metrics.Height * 5 / 4).Ceil()
+	advance, _ := regularFace.GlyphAdvance('0')
+	charWidth := advance.Ceil()
+
+	width := Width*charWidth + 2*pngPadding
+	height := len(lines)*lineHeight + 2*pngPadding
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	drawer := &font.Drawer{
+		Dst: img,
+		Src: image.NewUniform(color.Black),
+	}
+	for i, line := range lines {
+		drawer.Face = regularFace
+		if line.Bold {
+			drawer.Face = boldFace
+		}
+
+		x := pngPadding
+		free := (Width - utf8.RuneCountInString(line.Text)) * charWidth
+		switch line.Align {
+		case AlignCenter:
+			x += free / 2
+		case AlignRight:
+			x += free
+		}
+		y := pngPadding + i*lineHeight + metrics.Ascent.Ceil()
+
+		drawer.Dot = fixed.P(x, y)
+		drawer.DrawString(line.Text)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,185 @@
+// Package receipt renders proof of purchase (struk) for customers.
+//
+// The layout is built once as lines of a fixed-width receipt, then drawn
+// by each renderer, so the image, the PDF and a receipt printer look alike.
+package receipt
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+// Width is the number of characters per line, as on a 58 mm receipt printer.
+const Width = 32
+
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// Line is a line of the receipt. Text never exceeds Width characters.
+type Line struct {
+	Text  string
+	Bold  bool
+	Align Align
+}
+
+// Receipt is a successful transaction as shown to the customer.
+type Receipt struct {
+	StoreName string
+	// Header is printed under the store name, e.g. the address.
+	// Footer replaces the default "Terima kasih".
+	Header      []string
+	Footer      []string
+	Time        time.Time
+	RefID       string
+	ProductName string
+	CustomerNo  string
+	SN          string
+	// PLN prepaid only, see types.DigiflazzPLNToken
+	CustomerName string
+	TariffPower  string
+	KWh          string
+	// Price is the selling price, not the Digiflazz price
+	Price int64
+}
+
+// Lines lays out the receipt.
+func (r *Receipt) Lines() []Line {
+	var lines []Line
+	separator := Line{Text: strings.Repeat("-", Width)}
+
+	for _, text := range wrap(r.StoreName, Width) {
+		lines = append(lines, Line{Text: text, Bold: true, Align: AlignCenter})
+	}
+	for _, header := range r.Header {
+		for _, text := range wrap(header, Width) {
+			lines = append(lines, Line{Text: text, Align: AlignCenter})
+		}
+	}
+	lines = append(lines, Line{Text: "STRUK PEMBELIAN", Align: AlignCenter})
+	lines = append(lines, separator)
+
+	lines = append(lines, field("Tanggal", r.Time.Format("2 Jan 2006 15:04"))...)
+	lines = append(lines, field("Ref ID", r.RefID)...)
+	lines = append(lines, field("Produk", r.ProductName)...)
+	lines = append(lines, field("No. Pel", r.CustomerNo)...)
+
+	if r.SN != "" {
+		lines = append(lines, separator)
+		lines = append(lines, Line{Text: "SN/Token:"})
+		for _, text := range wrap(FormatToken(r.SN), Width) {
+			lines = append(lines, Line{Text: text, Bold: true, Align: AlignCenter})
+		}
+		if r.CustomerName != "" {
+			lines = append(lines, field("Nama", r.CustomerName)...)
+		}
+		if r.TariffPower != "" {
+			lines = append(lines, field("Tarif", r.TariffPower)...)
+		}
+		if r.KWh != "" {
+			lines = append(lines, field("kWh", r.KWh)...)
+		}
+	}
+
+	lines = append(lines, separator)
+	price := util.Sprintf("Rp %d", r.Price)
+	lines = append(lines, Line{
+		Text: "TOTAL" + strings.Repeat(" ", max(Width-len("TOTAL")-utf8.RuneCountInString(price), 1)) + price,
+		Bold: true,
+	})
+	lines = append(lines, separator)
+	footer := r.Footer
+	if len(footer) == 0 {
+		footer = []string{"Terima kasih"}
+	}
+	for _, f := range footer {
+		for _, text := range wrap(f, Width) {
+			lines = append(lines, Line{Text: text, Align: AlignCenter})
+		}
+	}
+
+	return lines
+}
+
+// FormatToken splits a numeric SN, such as a 20-digit PLN token, into groups
+// of four digits. Other SNs are returned unchanged.
+func FormatToken(sn string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(sn))
+	if len(digits) < 8 || strings.IndexFunc(digits, func(r rune) bool { return !unicode.IsDigit(r) }) != -1 {
+		return sn
+	}
+
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// field writes "Label   : value", wrapping long values under the value column.
+func field(label, value string) []Line {
+	const labelWidth = 8
+	prefix := label + strings.Repeat(" ", labelWidth-len(label)) + ": "
+	indent := strings.Repeat(" ", len(prefix))
+
+	var lines []Line
+	for i, text := range wrap(value, Width-len(prefix)) {
+		if i == 0 {
+			lines = append(lines, Line{Text: prefix + text})
+		} else {
+			lines = append(lines, Line{Text: indent + text})
+		}
+	}
+	return lines
+}
+
+// wrap breaks text into lines of at most width characters, at spaces when possible.
+func wrap(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	var line []rune
+	for _, word := range words {
+		w := []rune(word)
+		// Break words longer than a line
+		for len(w) > width {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			lines = append(lines, string(w[:width]))
+			w = w[width:]
+		}
+		if len(line) > 0 && len(line)+1+len(w) > width {
+			lines = append(lines, string(line))
+			line = nil
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, w...)
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
@@ -0,0 +1,140 @@
+package receipt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/channel"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+)
+
+// ErrNotSuccessful is returned by Load for transactions that didn't succeed.
+var ErrNotSuccessful = errors.New("receipt: transaction is not successful")
+
+// Load builds the receipt of a transaction. It also returns the transaction,
+// so callers can check who owns it.
+func Load(ctx context.Context, refId string) (*Receipt, *database.Transaction, error) {
+	trx, err := database.Sqlc.GetTransactionByRefID(ctx, refId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if trx.Status != string(types.DigiflazzTrxStatusSuccess) {
+		return nil, trx, ErrNotSuccessful
+	}
+
+	// Prefer the product name, the SKU code means nothing to customers
+	productName := trx.BuyerSkuCode
+	product, err := database.Sqlc.GetPrepaidProductBySKUCode(ctx, trx.BuyerSkuCode)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, err
+	}
+	if product.ID != 0 {
+		productName = product.Name
+	}
+
+	r := &Receipt{
+		StoreName:   config.Cfg.AppName,
+		Header:      config.Cfg.ReceiptHeader,
+		Footer:      config.Cfg.ReceiptFooter,
+		Time:        trx.UpdatedAt.Time.In(config.Cfg.AppLocation),
+		RefID:       trx.RefID,
+		ProductName: productName,
+		CustomerNo:  trx.CustomerNo,
+		Price:       trx.Price + config.Cfg.SellingPriceMarkup,
+	}
+
+	// Customers paid what was debited from their wallet
+	purchase, err := database.Sqlc.GetWalletEntry(ctx, &database.GetWalletEntryParams{
+		Kind:  "purchase",
+		RefID: trx.RefID,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, err
+	}
+	if purchase.ID != 0 {
+		r.Price = -purchase.Amount
+	}
+	if trx.Sn != nil {
+		r.SN = *trx.Sn
+	}
+
+	// Show the PLN token apart from the customer name, tariff and kWh
+	token, err := database.Sqlc.GetPLNTokenByRefID(ctx, trx.RefID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, err
+	}
+	if token.RefID != "" {
+		pln := &types.DigiflazzPLNToken{Token: token.Token}
+		if token.CustomerName != nil {
+			pln.CustomerName = *token.CustomerName
+		}
+		if token.Tariff != nil {
+			pln.Tariff = *token.Tariff
+		}
+		if token.Power != nil {
+			pln.Power = *token.Power
+		}
+		if token.Kwh != nil {
+			pln.KWh = *token.Kwh
+		}
+		r.SN = pln.Token
+		r.CustomerName = pln.CustomerName
+		r.TariffPower = pln.TariffPower()
+		r.KWh = pln.KWh
+	}
+	return r, trx, nil
+}
+
+// Send renders the receipt in each format ("png" or "pdf") and sends it to the chat.
+func Send(ctx context.Context, chatId int64, r *Receipt, formats []string) error {
+	caption := fmt.Sprintf("Struk <code>%s</code>", r.RefID)
+
+	for _, format := range formats {
+		switch format {
+		case "png":
+			data, err := PNG(r)
+			if err != nil {
+				return err
+			}
+			err = channel.Send(ctx, chatId, &channel.Message{
+				Text:     caption,
+				Document: &channel.Document{Name: "struk-" + r.RefID + ".png", Data: data, Image: true},
+			})
+			if err != nil {
+				return err
+			}
+		case "pdf":
+			data, err := PDF(r)
+			if err != nil {
+				return err
+			}
+			err = channel.Send(ctx, chatId, &channel.Message{
+				Text:     caption,
+				Document: &channel.Document{Name: "struk-" + r.RefID + ".pdf", Data: data},
+			})
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("receipt: unknown format %s", format)
+		}
+	}
+	return nil
+}
+
+// AutoSend sends the receipt of a successful transaction in the formats of RECEIPT_AUTO_SEND.
+func AutoSend(ctx context.Context, chatId int64, refId string) error {
+	if len(config.Cfg.ReceiptAutoSend) == 0 {
+		return nil
+	}
+
+	r, _, err := Load(ctx, refId)
+	if err != nil {
+		return err
+	}
+	return Send(ctx, chatId, r, config.Cfg.ReceiptAutoSend)
+}
@@ -0,0 +1,49 @@
+package receipt
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+)
+
+// SNText describes the SN after the status sentence of a transaction message.
+// A PLN token is grouped for reading, with its details on separate lines.
+func SNText(sn string) string {
+	token, ok := types.ParseDigiflazzPLNToken(sn)
+	if !ok {
+		return fmt.Sprintf(" SN: <code>%s</code>. ", html.EscapeString(sn))
+	}
+
+	var textB strings.Builder
+	textB.WriteString(fmt.Sprintf("\n\nToken: <code>%s</code>\n", FormatToken(token.Token)))
+	if token.CustomerName != "" {
+		textB.WriteString(fmt.Sprintf("Nama: %s\n", html.EscapeString(token.CustomerName)))
+	}
+	if tariffPower := token.TariffPower(); tariffPower != "" {
+		textB.WriteString(fmt.Sprintf("Tarif/Daya: %s\n", html.EscapeString(tariffPower)))
+	}
+	if token.KWh != "" {
+		textB.WriteString(fmt.Sprintf("kWh: %s\n", html.EscapeString(token.KWh)))
+	}
+	textB.WriteString("\n")
+	return textB.String()
+}
+
+// RefundText tells the customer that the price of a failed transaction went
+// back to their wallet.
+func RefundText(ctx context.Context, refund *database.WalletEntry) (string, error) {
+	balance, err := database.Sqlc.GetWalletBalance(ctx, refund.ChatID)
+	if err != nil {
+		return "", err
+	}
+	return util.Sprintf(
+		"\n\n💸 <b>Dana Rp %d dikembalikan</b> ke dompet. Saldo dompet: Rp %d",
+		refund.Amount,
+		balance,
+	), nil
+}
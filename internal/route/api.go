@@ -0,0 +1,410 @@
+package route
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/digiflazz-bot/database"
+	"github.com/fidrasofyan/digiflazz-bot/database/repository"
+	"github.com/fidrasofyan/digiflazz-bot/internal/bot"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/handler"
+	"github.com/fidrasofyan/digiflazz-bot/internal/middleware"
+	"github.com/fidrasofyan/digiflazz-bot/internal/service"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Page sizes of list endpoints
+const (
+	apiDefaultLimit = 50
+	apiMaxLimit     = 200
+)
+
+// apiOK sends data in the same envelope as the errors of the ErrorHandler.
+func apiOK(c *fiber.Ctx, status int, data any) error {
+	return c.Status(status).JSON(&fiber.Map{
+		"ok":   true,
+		"data": data,
+	})
+}
+
+// apiPage reads the limit and offset query parameters.
+func apiPage(c *fiber.Ctx) (limit, offset int64, err error) {
+	limit, offset = apiDefaultLimit, 0
+	if s := c.Query("limit"); s != "" {
+		limit, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || limit < 1 || limit > apiMaxLimit {
+			return 0, 0, fiber.NewError(400, "limit must be between 1 and "+strconv.Itoa(apiMaxLimit))
+		}
+	}
+	if s := c.Query("offset"); s != "" {
+		offset, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || offset < 0 {
+			return 0, 0, fiber.NewError(400, "offset must not be negative")
+		}
+	}
+	return limit, offset, nil
+}
+
+// apiQuery returns a query parameter, or nil if it is empty.
+func apiQuery(c *fiber.Ctx, key string) *string {
+	s := strings.TrimSpace(c.Query(key))
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// apiTime parses a date (2006-01-02, in APP_TIMEZONE) or an RFC 3339 time.
+// With endOfDay, a date means the end of that day.
+func apiTime(c *fiber.Ctx, key string, endOfDay bool) (sql.NullTime, error) {
+	s := c.Query(key)
+	if s == "" {
+		return sql.NullTime{}, nil
+	}
+	// Times are stored in the local zone and compared as text
+	if t, err := time.ParseInLocation(time.DateOnly, s, config.Cfg.AppLocation); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return sql.NullTime{Time: t.Local(), Valid: true}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return sql.NullTime{}, fiber.NewError(400, key+" must be a date (2006-01-02) or an RFC 3339 time")
+	}
+	return sql.NullTime{Time: t.Local(), Valid: true}, nil
+}
+
+func apiProduct(p *database.PrepaidProduct) *types.APIProduct {
+	return &types.APIProduct{
+		Code:           p.BuyerSkuCode,
+		Name:           p.Name,
+		Category:       p.Category,
+		Brand:          p.Brand,
+		Type:           p.Type,
+		Seller:         p.SellerName,
+		Price:          p.Price,
+		Active:         p.BuyerProductStatus && p.SellerProductStatus,
+		UnlimitedStock: p.UnlimitedStock,
+		Stock:          p.Stock,
+		Multi:          p.Multi,
+		StartCutOff:    p.StartCutOff,
+		EndCutOff:      p.EndCutOff,
+		Description:    p.Description,
+	}
+}
+
+func apiTransaction(t *database.Transaction) *types.APITransaction {
+	return &types.APITransaction{
+		RefID:       t.RefID,
+		ChatID:      t.ChatID,
+		ProductCode: t.BuyerSkuCode,
+		CustomerNo:  t.CustomerNo,
+		Price:       t.Price,
+		Status:      t.Status,
+		RC:          t.Rc,
+		SN:          t.Sn,
+		Message:     t.Message,
+		CreatedAt:   t.CreatedAt.Time,
+		UpdatedAt:   t.UpdatedAt.Time,
+	}
+}
+
+// apiUser builds a user from its chat. user is nil for chats that only have a role.
+func apiUser(ctx context.Context, chatId int64, user *database.User, role bot.Role) (*types.APIUser, error) {
+	balance, err := database.Sqlc.GetWalletBalance(ctx, chatId)
+	if err != nil {
+		return nil, err
+	}
+
+	apiUser := &types.APIUser{
+		ChatID:        chatId,
+		Role:          role.String(),
+		WalletBalance: balance,
+	}
+	if user != nil {
+		apiUser.Username = user.Username
+		apiUser.FirstName = user.FirstName
+		apiUser.LastName = user.LastName
+		if user.CreatedAt.Valid {
+			apiUser.CreatedAt = &user.CreatedAt.Time
+		}
+	}
+	return apiUser, nil
+}
+
+// APIListProducts lists products, filtered by category, brand, type and a search on the name or code.
+func APIListProducts() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit, offset, err := apiPage(c)
+		if err != nil {
+			return err
+		}
+
+		products, err := database.Sqlc.SearchPrepaidProducts(c.UserContext(), &database.SearchPrepaidProductsParams{
+			Category: apiQuery(c, "category"),
+			Brand:    apiQuery(c, "brand"),
+			Type:     apiQuery(c, "type"),
+			Search:   apiQuery(c, "search"),
+			Limit:    limit,
+			Offset:   offset,
+		})
+		if err != nil {
+			return util.NewError(err)
+		}
+
+		data := make([]*types.APIProduct, len(products))
+		for i, p := range products {
+			data[i] = apiProduct(p)
+		}
+		return apiOK(c, 200, data)
+	}
+}
+
+func APIGetProduct() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		product, err := database.Sqlc.GetPrepaidProductBySKUCode(c.UserContext(), c.Params("code"))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fiber.NewError(404, "Product not found")
+			}
+			return util.NewError(err)
+		}
+		return apiOK(c, 200, apiProduct(product))
+	}
+}
+
+// APICreateTransaction sends a transaction and returns it as stored, which
+// may still be pending.
+func APICreateTransaction() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req types.APICreateTransactionRequest
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(400, "Invalid JSON body")
+		}
+		if req.ProductCode == "" || req.CustomerNo == "" {
+			return fiber.NewError(400, "product_code and customer_no are required")
+		}
+
+		refId, err := handler.CreateTransaction(c.UserContext(), &handler.CreateTransactionParams{
+			Code:           req.ProductCode,
+			Number:         req.CustomerNo,
+			AllowDuplicate: req.AllowDuplicate,
+		})
+		switch {
+		case errors.Is(err, handler.ErrProductNotFound):
+			return fiber.NewError(404, "Product not found")
+		case errors.Is(err, handler.ErrInvalidNumber):
+			return fiber.NewError(400, "Invalid customer_no")
+		case errors.Is(err, handler.ErrProductUnavailable), errors.Is(err, handler.ErrDuplicateTransaction):
+			return fiber.NewError(409, err.Error())
+		case err != nil:
+			return util.NewError(err)
+		}
+		log.Printf("API key %d (%s): transaction %s", middleware.APIKeyOf(c).ID, middleware.APIKeyOf(c).Name, refId)
+
+		trx, err := database.Sqlc.GetTransactionByRefID(c.UserContext(), refId)
+		if err != nil {
+			return util.NewError(err)
+		}
+		return apiOK(c, 201, apiTransaction(trx))
+	}
+}
+
+// APIListTransactions lists transactions, newest first, filtered by chat_id,
+// status, customer_no and created_at (from, to).
+func APIListTransactions() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit, offset, err := apiPage(c)
+		if err != nil {
+			return err
+		}
+		from, err := apiTime(c, "from", false)
+		if err != nil {
+			return err
+		}
+		to, err := apiTime(c, "to", true)
+		if err != nil {
+			return err
+		}
+		var chatId *int64
+		if s := c.Query("chat_id"); s != "" {
+			id, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return fiber.NewError(400, "Invalid chat_id")
+			}
+			chatId = &id
+		}
+
+		transactions, err := database.Sqlc.ListTransactions(c.UserContext(), &database.ListTransactionsParams{
+			ChatID:        chatId,
+			Status:        apiQuery(c, "status"),
+			CustomerNo:    apiQuery(c, "customer_no"),
+			CreatedAfter:  from,
+			CreatedBefore: to,
+			Limit:         limit,
+			Offset:        offset,
+		})
+		if err != nil {
+			return util.NewError(err)
+		}
+
+		data := make([]*types.APITransaction, len(transactions))
+		for i, t := range transactions {
+			data[i] = apiTransaction(t)
+		}
+		return apiOK(c, 200, data)
+	}
+}
+
+func APIGetTransaction() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		trx, err := database.Sqlc.GetTransactionByRefID(c.UserContext(), c.Params("ref_id"))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fiber.NewError(404, "Transaction not found")
+			}
+			return util.NewError(err)
+		}
+		return apiOK(c, 200, apiTransaction(trx))
+	}
+}
+
+// APIBalance returns the Digiflazz deposit.
+func APIBalance() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		res, err := service.Digiflazz.CheckBalance(c.UserContext())
+		if err != nil {
+			return util.NewError(err)
+		}
+		return apiOK(c, 200, &types.APIBalance{Deposit: int64(res.Data.Deposit)})
+	}
+}
+
+// APIListUsers lists the users who started the bot and the chats that have a role.
+func APIListUsers() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		users, err := database.Sqlc.ListUsers(ctx)
+		if err != nil {
+			return util.NewError(err)
+		}
+		chatRoles, err := bot.ChatRoles(ctx)
+		if err != nil {
+			return util.NewError(err)
+		}
+
+		usersById := make(map[int64]*database.User, len(users))
+		chatIds := make([]int64, 0, len(users))
+		for _, u := range users {
+			usersById[u.ID] = u
+			chatIds = append(chatIds, u.ID)
+		}
+		for chatId := range chatRoles {
+			if _, ok := usersById[chatId]; !ok {
+				chatIds = append(chatIds, chatId)
+			}
+		}
+		slices.Sort(chatIds)
+
+		data := make([]*types.APIUser, len(chatIds))
+		for i, chatId := range chatIds {
+			data[i], err = apiUser(ctx, chatId, usersById[chatId], chatRoles[chatId])
+			if err != nil {
+				return util.NewError(err)
+			}
+		}
+		return apiOK(c, 200, data)
+	}
+}
+
+func APIGetUser() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		chatId, err := strconv.ParseInt(c.Params("chat_id"), 10, 64)
+		if err != nil {
+			return fiber.NewError(400, "Invalid chat_id")
+		}
+		user, err := apiUserByChat(c.UserContext(), chatId)
+		if err != nil {
+			return err
+		}
+		return apiOK(c, 200, user)
+	}
+}
+
+// APISetUserRole sets the role of a chat, or resets it to the config with "default".
+func APISetUserRole() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		chatId, err := strconv.ParseInt(c.Params("chat_id"), 10, 64)
+		if err != nil || chatId == repository.APIChatID {
+			return fiber.NewError(400, "Invalid chat_id")
+		}
+
+		var req types.APISetRoleRequest
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(400, "Invalid JSON body")
+		}
+		if req.Role == "default" {
+			err = bot.ResetRole(ctx, chatId)
+		} else {
+			role, ok := bot.ParseRole(req.Role)
+			if !ok {
+				return fiber.NewError(400, "role must be guest, customer, user, admin or default")
+			}
+			err = bot.SetRole(ctx, chatId, role, repository.APIChatID)
+		}
+		if err != nil {
+			return util.NewError(err)
+		}
+		log.Printf("API key %d (%s): role of %d set to %s", middleware.APIKeyOf(c).ID, middleware.APIKeyOf(c).Name, chatId, req.Role)
+
+		// Keep the command menu in sync. The chat may not have started the bot yet.
+		err = TelegramRouter.SyncCommands(ctx, chatId)
+		if err != nil {
+			log.Printf("Error syncing commands of %d: %v", chatId, err)
+		}
+
+		user, err := apiUserByChat(ctx, chatId)
+		if err != nil {
+			return err
+		}
+		return apiOK(c, 200, user)
+	}
+}
+
+// apiUserByChat returns the user of a chat, or a 404 error if the chat never
+// started the bot and has no role.
+func apiUserByChat(ctx context.Context, chatId int64) (*types.APIUser, error) {
+	user, err := database.Sqlc.GetUser(ctx, chatId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, util.NewError(err)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		user = nil
+	}
+
+	chatRoles, err := bot.ChatRoles(ctx)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+	role, hasRole := chatRoles[chatId]
+	if user == nil && !hasRole {
+		return nil, fiber.NewError(404, "User not found")
+	}
+
+	apiUser, err := apiUser(ctx, chatId, user, role)
+	if err != nil {
+		return nil, util.NewError(err)
+	}
+	return apiUser, nil
+}
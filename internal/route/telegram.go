@@ -1,21 +1,126 @@
 package route
 
 import (
-	"database/sql"
+	"context"
 	"errors"
+	"log"
 	"regexp"
-	"slices"
-	"strings"
 
 	"github.com/fidrasofyan/digiflazz-bot/database/repository"
-	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/bot"
 	"github.com/fidrasofyan/digiflazz-bot/internal/handler"
+	"github.com/fidrasofyan/digiflazz-bot/internal/service"
 	"github.com/fidrasofyan/digiflazz-bot/internal/types"
 	"github.com/fidrasofyan/digiflazz-bot/internal/util"
 	"github.com/gofiber/fiber/v2"
 )
 
-var trxRegex = regexp.MustCompile(`^([A-Za-z0-9-]+)\s+(\d+)$`)
+// TelegramRouter holds every Telegram command. The webhook and long polling both use it.
+var TelegramRouter = newTelegramRouter()
+
+func newTelegramRouter() *bot.Router {
+	r := bot.NewRouter()
+
+	r.Handle(&bot.Command{
+		Name:        "start",
+		Description: "Mulai bot",
+		Role:        bot.RoleGuest,
+		Handler:     handler.Start,
+	})
+	r.Handle(&bot.Command{
+		Name:        "daftar",
+		Description: "Daftar sebagai pelanggan",
+		Role:        bot.RoleGuest,
+		Handler:     r.Register,
+	})
+	r.Handle(&bot.Command{
+		Name:        "daftar produk",
+		Aliases:     []string{"produk"},
+		Description: "Daftar produk",
+		Role:        bot.RoleCustomer,
+		Handler:     handler.ProductList,
+	})
+	r.Handle(&bot.Command{
+		Name:        "cek saldo",
+		Aliases:     []string{"saldo"},
+		Description: "Cek saldo",
+		Role:        bot.RoleCustomer,
+		Expensive:   true,
+		Handler:     handler.CheckBalance,
+	})
+	r.Handle(&bot.Command{
+		Name:        "refresh produk",
+		Aliases:     []string{"refresh"},
+		Description: "Perbarui daftar produk",
+		Role:        bot.RoleUser,
+		Expensive:   true,
+		Handler:     handler.RefreshProducts,
+	})
+	// Commands with an argument go before transactions, whose pattern also matches them
+	r.Handle(&bot.Command{
+		Pattern:     regexp.MustCompile(`^struk\s+\S+$`),
+		Usage:       []string{"struk ref_id"},
+		Description: "Kirim struk transaksi sukses",
+		Role:        bot.RoleCustomer,
+		Expensive:   true,
+		Handler:     handler.Receipt,
+	})
+	r.Handle(&bot.Command{
+		Name:        "dompet",
+		Description: "Saldo dan riwayat dompet",
+		Role:        bot.RoleCustomer,
+		Handler:     handler.Wallet,
+	})
+	r.Handle(&bot.Command{
+		Pattern:     regexp.MustCompile(`^topup\s+\d+$`),
+		Usage:       []string{"topup nominal", "topup 50000"},
+		Description: "Isi saldo dompet",
+		Role:        bot.RoleCustomer,
+		Handler:     handler.Topup,
+	})
+	r.Handle(&bot.Command{
+		Pattern:     regexp.MustCompile(`^(hapus\s+pin\s+\S+|pin\s+\S+(\s+\S+)?)$`),
+		Usage:       []string{"pin baru", "pin lama baru", "hapus pin lama"},
+		Description: "Atur, ganti atau hapus PIN transaksi",
+		Role:        bot.RoleCustomer,
+		Handler:     handler.Pin,
+	})
+	r.Handle(&bot.Command{
+		Pattern:     regexp.MustCompile(`^limit\s+\d+(\s+default|\s+\d+\s+\d+\s+\d+)?$`),
+		Usage:       []string{"limit chat_id", "limit chat_id maks_per_trx maks_total_harian maks_jumlah_harian", "limit chat_id default"},
+		Description: "Lihat atau atur limit transaksi (0 = tanpa batas)",
+		Role:        bot.RoleAdmin,
+		Handler:     handler.Limit,
+	})
+	r.Handle(&bot.Command{
+		Pattern:     regexp.MustCompile(`^role\s+\d+(\s+(guest|customer|user|admin|default))?$`),
+		Usage:       []string{"role chat_id", "role chat_id guest|customer|user|admin", "role chat_id default"},
+		Description: "Lihat atau atur role pengguna",
+		Role:        bot.RoleAdmin,
+		Handler:     r.ManageRole,
+	})
+	r.Handle(&bot.Command{
+		Name:        "_transaction",
+		Pattern:     regexp.MustCompile(`^[a-z0-9-]+\s+(\+62)?[0-9-]+$`),
+		Usage:       []string{"kode_produk nomor_tujuan", "IG100 085808580858"},
+		Description: "Transaksi",
+		Role:        bot.RoleCustomer,
+		Expensive:   true,
+		Handler:     handler.Transaction,
+	})
+	r.Handle(&bot.Command{
+		Name:        "help",
+		Description: "Bantuan",
+		Role:        bot.RoleCustomer,
+		Handler:     r.Help,
+	})
+
+	r.HandleCallback(handler.ApprovalCallbackPrefix, bot.RoleAdmin, handler.Approval)
+	r.HandleCallback(handler.TopupCallbackPrefix, bot.RoleAdmin, handler.TopupDecision)
+	r.NotFound(handler.NotFound)
+
+	return r
+}
 
 func Telegram() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -24,170 +129,56 @@ func Telegram() fiber.Handler {
 			return util.NewError(err)
 		}
 
-		var chatId int64
-		var command string
-
-		// Is it callback query?
-		if req.CallbackQuery != nil {
-			// Set chat id
-			chatId = req.CallbackQuery.From.Id
-		} else {
-			// Set chat id
-			chatId = req.Message.Chat.Id
-
-			// Only text message is supported
-			if req.Message.Text == "" {
-				return c.Status(200).JSON(types.TelegramResponse{
-					Method:      types.TelegramMethodSendMessage,
-					ChatId:      req.Message.Chat.Id,
-					ParseMode:   types.TelegramParseModeHTML,
-					Text:        "<i>Only text command is supported</i>",
-					ReplyMarkup: types.DefaultReplyMarkup,
-				})
-			}
-
-			// Set command
-			command = strings.TrimSpace(strings.ToLower(
-				req.Message.Text,
-			))
-			// Limit command length
-			if len(command) > 30 {
-				command = command[:30]
-			}
-			// Remove leading slashes
-			command = strings.TrimLeft(command, "/")
-			// Remove leading underscores to prevent calling internal commands
-			command = strings.TrimLeft(command, "_")
+		resp, err := TelegramRouter.Dispatch(c.UserContext(), &req)
+		if err != nil {
+			return err
 		}
-
-		// Is it "cancel" command?
-		if command == "cancel" {
-			// Delete chat
-			err := repository.TelegramDeleteChat(c.UserContext(), chatId)
-			if err != nil {
-				return util.NewError(err)
-			}
-			return c.Status(200).JSON(types.TelegramResponse{
-				Method:      types.TelegramMethodSendMessage,
-				ChatId:      req.Message.Chat.Id,
-				ParseMode:   types.TelegramParseModeHTML,
-				Text:        "<i>Dibatalkan</i>",
-				ReplyMarkup: types.DefaultReplyMarkup,
-			})
+		if resp == nil {
+			return c.Status(200).SendString("OK")
 		}
+		return c.Status(200).JSON(resp)
+	}
+}
 
-		// Get chat
-		chat, err := repository.TelegramGetChat(c.UserContext(), chatId)
-		if err != nil && !errors.Is(err, sql.ErrNoRows) {
-			return util.NewError(err)
-		}
-		if chat.ID != 0 {
-			// Set command
-			command = chat.Command
-		}
+// TelegramErrorResponse builds the reply for an update whose handler failed.
+func TelegramErrorResponse(ctx context.Context, req *types.TelegramUpdate, err error) *types.TelegramResponse {
+	log.Printf("Error: %v", err)
 
-		// Is chat ID allowed?
-		if command != "start" && !slices.Contains(config.Cfg.TelegramAllowedIds, chatId) {
-			return c.Status(200).JSON(types.TelegramResponse{
-				Method:      types.TelegramMethodSendMessage,
-				ChatId:      req.Message.Chat.Id,
-				ParseMode:   types.TelegramParseModeHTML,
-				Text:        "<i>Access denied</i>",
-				ReplyMarkup: types.DefaultReplyMarkup,
-			})
-		}
+	text := "<i>Something went wrong</i>"
+	if errors.Is(err, fiber.ErrRequestTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		text = "<i>Request timeout</i>"
+	}
 
-		switch command {
-		// Start
-		case "start":
-			resp, err := handler.Start(c.UserContext(), &req)
-			if err != nil {
-				return util.NewError(err)
-			}
-			if resp == nil {
-				return c.Status(200).SendString("OK")
-			}
-			return c.Status(200).JSON(resp)
-
-		// Product list
-		case "daftar produk":
-			resp, err := handler.ProductList(c.UserContext(), &req)
-			if err != nil {
-				return util.NewError(err)
-			}
-			if resp == nil {
-				return c.Status(200).SendString("OK")
-			}
-			return c.Status(200).JSON(resp)
-
-		// Refresh products
-		case "refresh produk":
-			resp, err := handler.RefreshProducts(c.UserContext(), &req)
-			if err != nil {
-				return util.NewError(err)
-			}
-			if resp == nil {
-				return c.Status(200).SendString("OK")
-			}
-			return c.Status(200).JSON(resp)
-
-		// Check balance
-		case "cek saldo":
-			resp, err := handler.CheckBalance(c.UserContext(), &req)
-			if err != nil {
-				return util.NewError(err)
-			}
-			if resp == nil {
-				return c.Status(200).SendString("OK")
-			}
-			return c.Status(200).JSON(resp)
-
-		// Transaction
-		case "_transaction":
-			resp, err := handler.Transaction(c.UserContext(), &req)
-			if err != nil {
-				return util.NewError(err)
-			}
-			if resp == nil {
-				return c.Status(200).SendString("OK")
-			}
-			return c.Status(200).JSON(resp)
-
-		// Help
-		case "help":
-			resp, err := handler.Help(c.UserContext(), &req)
-			if err != nil {
-				return util.NewError(err)
-			}
-			if resp == nil {
-				return c.Status(200).SendString("OK")
-			}
-			return c.Status(200).JSON(resp)
-
-		// Not found
-		default:
-			// Is it transaction?
-			if req.Message != nil {
-				req.Message.Text = strings.TrimSpace(req.Message.Text)
-				req.Message.Text = strings.ReplaceAll(req.Message.Text, "-", "")
-				req.Message.Text = strings.ReplaceAll(req.Message.Text, "+62", "0")
-				if trxRegex.MatchString(req.Message.Text) {
-					resp, err := handler.Transaction(c.UserContext(), &req)
-					if err != nil {
-						return util.NewError(err)
-					}
-					if resp == nil {
-						return c.Status(200).SendString("OK")
-					}
-					return c.Status(200).JSON(resp)
-				}
-			}
-
-			resp, err := handler.NotFound(c.UserContext(), &req)
-			if err != nil || resp == nil {
-				return util.NewError(err)
-			}
-			return c.Status(200).JSON(resp)
+	if req.CallbackQuery != nil {
+		// Delete chat
+		_ = repository.TelegramDeleteChat(ctx, req.CallbackQuery.From.Id)
+
+		// Answer callback query
+		_ = service.Telegram.AnswerCallbackQuery(ctx, &service.TelegramAnswerCallbackQueryParams{
+			CallbackQueryId: req.CallbackQuery.Id,
+		})
+
+		return &types.TelegramResponse{
+			Method:    types.TelegramMethodEditMessageText,
+			MessageId: req.CallbackQuery.Message.MessageId,
+			ChatId:    req.CallbackQuery.Message.Chat.Id,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
 		}
 	}
+
+	if req.Message == nil {
+		return nil
+	}
+
+	// Delete chat
+	_ = repository.TelegramDeleteChat(ctx, req.Message.Chat.Id)
+
+	return &types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      req.Message.Chat.Id,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        text,
+		ReplyMarkup: types.DefaultReplyMarkup,
+	}
 }
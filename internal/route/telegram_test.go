@@ -0,0 +1,51 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/bot"
+)
+
+// commandKey identifies a command by its name, or its first usage if it only has a pattern.
+func commandKey(cmd *bot.Command) string {
+	if cmd == nil {
+		return ""
+	}
+	if cmd.Name != "" {
+		return cmd.Name
+	}
+	return cmd.Usage[0]
+}
+
+func TestTelegramRouterMatch(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"cek saldo", "cek saldo"},
+		{"/saldo", "cek saldo"},
+		{"Daftar Produk", "daftar produk"},
+		{"struk 0190a1b2-c3d4", "struk ref_id"},
+		{"topup 50000", "topup nominal"},
+		{"pin 1234", "pin baru"},
+		{"pin 1234 5678", "pin baru"},
+		{"hapus pin 1234", "pin baru"},
+		{"limit 12345", "limit chat_id"},
+		{"limit 12345 default", "limit chat_id"},
+		{"limit 12345 100000 500000 10", "limit chat_id"},
+		{"role 12345", "role chat_id"},
+		{"role 12345 customer", "role chat_id"},
+		{"IG100 085808580858", "_transaction"},
+		{"xld10 +62858-0858-0858", "_transaction"},
+		{"halo", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := commandKey(TelegramRouter.Match(tt.text))
+			if got != tt.want {
+				t.Errorf("Match(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package route
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/fidrasofyan/digiflazz-bot/internal/bot"
+	"github.com/fidrasofyan/digiflazz-bot/internal/channel"
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/handler"
+	"github.com/fidrasofyan/digiflazz-bot/internal/middleware"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
+	"github.com/fidrasofyan/digiflazz-bot/internal/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// WhatsAppVerify answers the challenge Meta sends when the webhook is set up.
+func WhatsAppVerify() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Query("hub.mode") != "subscribe" || c.Query("hub.verify_token") != config.Cfg.WhatsAppVerifyToken {
+			return c.Status(403).SendString("Forbidden")
+		}
+		return c.Status(200).SendString(c.Query("hub.challenge"))
+	}
+}
+
+// whatsappWorkers is the number of WhatsApp messages handled at the same time.
+const whatsappWorkers = 16
+
+// WhatsApp handles incoming WhatsApp messages with the Telegram router. Each
+// message becomes a Telegram update, and the reply is sent back through the
+// WhatsApp channel. The messages of a chat are handled in order.
+func WhatsApp(limiter *middleware.TelegramRateLimiter) fiber.Handler {
+	dispatcher := bot.NewDispatcher(whatsappWorkers)
+
+	return func(c *fiber.Ctx) error {
+		var req types.WhatsAppWebhook
+		if err := c.BodyParser(&req); err != nil {
+			return util.NewError(err)
+		}
+
+		for _, entry := range req.Entry {
+			for _, change := range entry.Changes {
+				names := make(map[string]string)
+				for _, contact := range change.Value.Contacts {
+					names[contact.WaId] = contact.Profile.Name
+				}
+				for _, message := range change.Value.Messages {
+					chatId, err := channel.WhatsAppChatId(message.From)
+					if err != nil {
+						log.Printf("WhatsApp: %v", err)
+						continue
+					}
+					name := names[message.From]
+					dispatcher.Dispatch(context.Background(), chatId, func(ctx context.Context) {
+						handleWhatsAppMessage(ctx, limiter, &message, name)
+					})
+				}
+			}
+		}
+
+		// Meta retries until it gets 200, so answer right away
+		return c.Status(200).SendString("OK")
+	}
+}
+
+// whatsappUpdate converts a WhatsApp message into a Telegram update. It
+// returns nil for messages the bot doesn't read, like images.
+func whatsappUpdate(ctx context.Context, message *types.WhatsAppMessage, name string) (*types.TelegramUpdate, error) {
+	var text, replyId string
+	switch message.Type {
+	case "text":
+		text = message.Text.Body
+	case "interactive":
+		reply := message.Interactive.ButtonReply
+		if message.Interactive.Type == "list_reply" {
+			reply = message.Interactive.ListReply
+		}
+		text = reply.Title
+		replyId = reply.Id
+	default:
+		return nil, nil
+	}
+
+	chatId, err := channel.WhatsAppChatId(message.From)
+	if err != nil {
+		return nil, err
+	}
+	date, _ := strconv.ParseInt(message.Timestamp, 10, 64)
+	firstName, lastName, _ := strings.Cut(name, " ")
+	user := types.TelegramUser{Id: chatId, FirstName: firstName, LastName: lastName}
+	chat := types.TelegramChat{Id: chatId, Type: "private", FirstName: firstName, LastName: lastName}
+
+	// Telegram update IDs are small, a hash with a high bit set can't clash
+	// with them and still lets the router skip redelivered messages
+	h := fnv.New64a()
+	h.Write([]byte(message.Id))
+	update := &types.TelegramUpdate{UpdateId: int64(h.Sum64()>>2) | 1<<61}
+
+	value, callback, err := channel.WhatsAppInput(ctx, chatId, text, replyId)
+	if err != nil {
+		return nil, err
+	}
+	if callback {
+		update.CallbackQuery = &types.TelegramCallbackQuery{
+			From: user,
+			Message: types.TelegramMessage{
+				Date: date,
+				From: user,
+				Chat: chat,
+			},
+			Data: value,
+		}
+	} else {
+		update.Message = &types.TelegramMessage{
+			Date: date,
+			From: user,
+			Chat: chat,
+			Text: value,
+		}
+	}
+	return update, nil
+}
+
+// handleWhatsAppMessage converts the message after the earlier messages of the
+// chat are handled, so a number typed back picks from the latest menu.
+func handleWhatsAppMessage(ctx context.Context, limiter *middleware.TelegramRateLimiter, message *types.WhatsAppMessage, name string) {
+	ctx, cancel := context.WithTimeout(ctx, handler.PurchaseTimeout())
+	defer cancel()
+
+	req, err := whatsappUpdate(ctx, message, name)
+	if err != nil {
+		log.Printf("WhatsApp: %v", err)
+		return
+	}
+	if req == nil {
+		return
+	}
+
+	resp, limited := limiter.Check(req)
+	if !limited {
+		var err error
+		resp, err = TelegramRouter.Dispatch(ctx, req)
+		if err != nil {
+			resp = TelegramErrorResponse(ctx, req, err)
+		}
+	}
+
+	msg := channel.FromResponse(resp)
+	if msg == nil {
+		return
+	}
+	var chatId int64
+	if req.CallbackQuery != nil {
+		chatId = req.CallbackQuery.From.Id
+	} else {
+		chatId = req.Message.Chat.Id
+	}
+	err = channel.Send(ctx, chatId, msg)
+	if err != nil {
+		log.Printf("WhatsApp: %v", err)
+	}
+}
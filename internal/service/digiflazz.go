@@ -5,100 +5,185 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"time"
 
-	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+	"github.com/fidrasofyan/digiflazz-bot/internal/types"
 )
 
-var digiflazzHttpClient = &http.Client{
-	Transport: &http.Transport{
-		DialContext: (&net.Dialer{
-			Timeout:   5 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		ForceAttemptHTTP2:   true,
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 100,
-		IdleConnTimeout:     90 * time.Second,
-		TLSHandshakeTimeout: 5 * time.Second,
-	},
-	Timeout: 10 * time.Second,
+// DigiflazzAPI is implemented by DigiflazzClient. Handlers and jobs use it
+// through the Digiflazz variable, so it can be replaced with a fake.
+type DigiflazzAPI interface {
+	CheckBalance(ctx context.Context) (*DigiflazzCheckBalanceResponse, error)
+	GetPrepaidPriceList(ctx context.Context) (*DigiflazzGetPrepaidPriceListResponse, error)
+	CreateTrx(ctx context.Context, params *DigiflazzCreateTrxParams) (*DigiflazzCreateTrxResponse, error)
 }
 
-type DigiflazzErrorResponse struct {
-	Data struct {
-		RC      string `json:"rc"`
-		Message string `json:"message"`
-	} `json:"data"`
+// Digiflazz is the client used by the bot, set by MustLoadClients.
+var Digiflazz DigiflazzAPI
+
+// DigiflazzTimeouts limits how long each endpoint may take.
+// The price list is large and transactions may wait for the seller.
+type DigiflazzTimeouts struct {
+	CheckBalance time.Duration
+	PriceList    time.Duration
+	Transaction  time.Duration
+}
+
+var DefaultDigiflazzTimeouts = DigiflazzTimeouts{
+	CheckBalance: 10 * time.Second,
+	PriceList:    30 * time.Second,
+	Transaction:  30 * time.Second,
+}
+
+type DigiflazzClientParams struct {
+	BaseUrl  string
+	Username string
+	ApiKey   string
+	// Testing sends transactions in development mode, they are never charged
+	Testing  bool
+	Timeouts DigiflazzTimeouts
 }
 
-func (e *DigiflazzErrorResponse) Error() string {
-	return fmt.Sprintf("Kode: %s - Pesan: %s", e.Data.RC, e.Data.Message)
+type DigiflazzClient struct {
+	baseUrl    string
+	username   string
+	apiKey     string
+	testing    bool
+	timeouts   DigiflazzTimeouts
+	httpClient *http.Client
+}
+
+func NewDigiflazzClient(params *DigiflazzClientParams) *DigiflazzClient {
+	return &DigiflazzClient{
+		baseUrl:  params.BaseUrl,
+		username: params.Username,
+		apiKey:   params.ApiKey,
+		testing:  params.Testing,
+		timeouts: params.Timeouts,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: (&net.Dialer{
+					Timeout:   5 * time.Second,
+					KeepAlive: 30 * time.Second,
+				}).DialContext,
+				ForceAttemptHTTP2:   true,
+				MaxIdleConns:        100,
+				MaxIdleConnsPerHost: 100,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: 5 * time.Second,
+			},
+		},
+	}
+}
+
+// DigiflazzError is a non-200 response. RC and Message are set when
+// Digiflazz explained the failure; Body keeps the raw response for logs.
+type DigiflazzError struct {
+	Endpoint   string
+	StatusCode int
+	RC         string
+	Message    string
+	Body       []byte
+}
+
+func (e *DigiflazzError) Error() string {
+	if e.RC == "" {
+		return fmt.Sprintf("digiflazz %s: HTTP %d: %s", e.Endpoint, e.StatusCode, bytes.TrimSpace(e.Body))
+	}
+	return fmt.Sprintf("Kode: %s - Pesan: %s", e.RC, e.Message)
+}
+
+// Rejected reports whether Digiflazz processed the request and refused it,
+// as opposed to an unexpected response such as a 404 page.
+func (e *DigiflazzError) Rejected() bool {
+	return e.RC != ""
 }
 
 // Sign
-func DigiflazzSign(suffix string) string {
+func (c *DigiflazzClient) Sign(suffix string) string {
 	h := md5.New()
-	h.Write([]byte(config.Cfg.DigiflazzUsername))
-	h.Write([]byte(config.Cfg.DigiflazzApiKey))
+	h.Write([]byte(c.username))
+	h.Write([]byte(c.apiKey))
 	h.Write([]byte(suffix))
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
-// Check balance
-type DigiflazzCheckBalanceResponse struct {
-	Data struct {
-		Deposit int `json:"deposit"`
-	} `json:"data"`
-}
-
-func DigiflazzCheckBalance(ctx context.Context) (*DigiflazzCheckBalanceResponse, error) {
-	data := struct {
-		Cmd      string `json:"cmd"`
-		Username string `json:"username"`
-		Sign     string `json:"sign"`
-	}{
-		Cmd:      "deposit",
-		Username: config.Cfg.DigiflazzUsername,
-		Sign:     DigiflazzSign("depo"),
+// post sends data to an endpoint and decodes the response into response.
+func (c *DigiflazzClient) post(ctx context.Context, endpoint string, timeout time.Duration, data any, response any) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	url := config.Cfg.DigiflazzBaseUrl + "/cek-saldo"
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseUrl+endpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, err
+		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	res, err := digiflazzHttpClient.Do(req)
+	res, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer res.Body.Close()
 
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
 	if res.StatusCode != 200 {
-		if res.StatusCode == 404 {
-			return nil, errors.New("404 page not found")
+		digiflazzError := &DigiflazzError{
+			Endpoint:   endpoint,
+			StatusCode: res.StatusCode,
+			Body:       body,
+		}
+		var errorResponse struct {
+			Data struct {
+				RC      string `json:"rc"`
+				Message string `json:"message"`
+			} `json:"data"`
 		}
-		var digiflazzError DigiflazzErrorResponse
-		err = json.NewDecoder(res.Body).Decode(&digiflazzError)
-		if err != nil {
-			return nil, err
+		if json.Unmarshal(body, &errorResponse) == nil {
+			digiflazzError.RC = errorResponse.Data.RC
+			digiflazzError.Message = errorResponse.Data.Message
 		}
-		return nil, &digiflazzError
+		return digiflazzError
+	}
+
+	return json.Unmarshal(body, response)
+}
+
+// Check balance
+type DigiflazzCheckBalanceResponse struct {
+	Data struct {
+		Deposit int `json:"deposit"`
+	} `json:"data"`
+}
+
+func (c *DigiflazzClient) CheckBalance(ctx context.Context) (*DigiflazzCheckBalanceResponse, error) {
+	data := struct {
+		Cmd      string `json:"cmd"`
+		Username string `json:"username"`
+		Sign     string `json:"sign"`
+	}{
+		Cmd:      "deposit",
+		Username: c.username,
+		Sign:     c.Sign("depo"),
 	}
 
 	var response DigiflazzCheckBalanceResponse
-	err = json.NewDecoder(res.Body).Decode(&response)
+	err := c.post(ctx, "/cek-saldo", c.timeouts.CheckBalance, data, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -128,49 +213,19 @@ type DigiflazzGetPrepaidPriceListResponse struct {
 	Data []DigiflazzPrepaidProduct `json:"data"`
 }
 
-func DigiflazzGetPrepaidPriceList(ctx context.Context) (*DigiflazzGetPrepaidPriceListResponse, error) {
+func (c *DigiflazzClient) GetPrepaidPriceList(ctx context.Context) (*DigiflazzGetPrepaidPriceListResponse, error) {
 	data := struct {
 		Cmd      string `json:"cmd"`
 		Username string `json:"username"`
 		Sign     string `json:"sign"`
 	}{
 		Cmd:      "prepaid",
-		Username: config.Cfg.DigiflazzUsername,
-		Sign:     DigiflazzSign("pricelist"),
-	}
-
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
-	url := config.Cfg.DigiflazzBaseUrl + "/price-list"
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	res, err := digiflazzHttpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != 200 {
-		if res.StatusCode == 404 {
-			return nil, errors.New("404 page not found")
-		}
-		var digiflazzError DigiflazzErrorResponse
-		err = json.NewDecoder(res.Body).Decode(&digiflazzError)
-		if err != nil {
-			return nil, err
-		}
-		return nil, &digiflazzError
+		Username: c.username,
+		Sign:     c.Sign("pricelist"),
 	}
 
 	var response DigiflazzGetPrepaidPriceListResponse
-	err = json.NewDecoder(res.Body).Decode(&response)
+	err := c.post(ctx, "/price-list", c.timeouts.PriceList, data, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -178,23 +233,16 @@ func DigiflazzGetPrepaidPriceList(ctx context.Context) (*DigiflazzGetPrepaidPric
 }
 
 // Create transaction
-type DigiflazzTrxStatus string
-
-var (
-	DigiflazzTrxStatusPending DigiflazzTrxStatus = "Pending"
-	DigiflazzTrxStatusSuccess DigiflazzTrxStatus = "Sukses"
-	DigiflazzTrxStatusFailed  DigiflazzTrxStatus = "Gagal"
-)
-
 type DigiflazzTrxData struct {
-	RefID        string             `json:"ref_id"`
-	CustomerNo   string             `json:"customer_no"`
-	BuyerSKUCode string             `json:"buyer_sku_code"`
-	Message      string             `json:"message"`
-	Status       DigiflazzTrxStatus `json:"status"`
-	RC           string             `json:"rc"`
-	SN           *string            `json:"sn"`
-	Price        int32              `json:"price"`
+	RefID          string                   `json:"ref_id"`
+	CustomerNo     string                   `json:"customer_no"`
+	BuyerSKUCode   string                   `json:"buyer_sku_code"`
+	Message        string                   `json:"message"`
+	Status         types.DigiflazzTrxStatus `json:"status"`
+	RC             string                   `json:"rc"`
+	SN             *string                  `json:"sn"`
+	Price          int32                    `json:"price"`
+	BuyerLastSaldo int32                    `json:"buyer_last_saldo"`
 }
 
 type DigiflazzCreateTrxResponse struct {
@@ -207,7 +255,7 @@ type DigiflazzCreateTrxParams struct {
 	CustomerNo   string
 }
 
-func DigiflazzCreateTrx(ctx context.Context, params *DigiflazzCreateTrxParams) (*DigiflazzCreateTrxResponse, error) {
+func (c *DigiflazzClient) CreateTrx(ctx context.Context, params *DigiflazzCreateTrxParams) (*DigiflazzCreateTrxResponse, error) {
 	data := struct {
 		Username     string `json:"username"`
 		BuyerSKUCode string `json:"buyer_sku_code"`
@@ -216,46 +264,16 @@ func DigiflazzCreateTrx(ctx context.Context, params *DigiflazzCreateTrxParams) (
 		Sign         string `json:"sign"`
 		Testing      bool   `json:"testing"`
 	}{
-		Username:     config.Cfg.DigiflazzUsername,
+		Username:     c.username,
 		BuyerSKUCode: params.BuyerSKUCode,
 		CustomerNo:   params.CustomerNo,
 		RefID:        params.RefID,
-		Sign:         DigiflazzSign(params.RefID),
-		Testing:      config.Cfg.AppEnv != "production",
-	}
-
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
-	url := config.Cfg.DigiflazzBaseUrl + "/transaction"
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	res, err := digiflazzHttpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != 200 {
-		if res.StatusCode == 404 {
-			return nil, errors.New("404 page not found")
-		}
-		var digiflazzError DigiflazzErrorResponse
-		err = json.NewDecoder(res.Body).Decode(&digiflazzError)
-		if err != nil {
-			return nil, err
-		}
-		return nil, &digiflazzError
+		Sign:         c.Sign(params.RefID),
+		Testing:      c.testing,
 	}
 
 	var response DigiflazzCreateTrxResponse
-	err = json.NewDecoder(res.Body).Decode(&response)
+	err := c.post(ctx, "/transaction", c.timeouts.Transaction, data, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,114 @@
+package service
+
+import "fmt"
+
+// DigiflazzRCCategory tells what a response code means for the transaction.
+type DigiflazzRCCategory string
+
+const (
+	DigiflazzRCSuccess     DigiflazzRCCategory = "success"
+	DigiflazzRCPending     DigiflazzRCCategory = "pending"
+	DigiflazzRCFailed      DigiflazzRCCategory = "failed"    // final, retrying won't help
+	DigiflazzRCRetryable   DigiflazzRCCategory = "retryable" // may succeed later or with another seller
+	DigiflazzRCConfigError DigiflazzRCCategory = "config"    // our account or request is wrong
+)
+
+// Recommended actions shown to staff
+const (
+	digiflazzActionNone          = ""
+	digiflazzActionWait          = "Tunggu status akhir, jangan kirim ulang"
+	digiflazzActionRetry         = "Coba lagi beberapa saat lagi"
+	digiflazzActionChangeSeller  = "Coba lagi nanti atau ganti produk/seller lain"
+	digiflazzActionCheckNumber   = "Periksa nomor tujuan"
+	digiflazzActionTopUp         = "Isi saldo deposit Digiflazz"
+	digiflazzActionCheckSettings = "Periksa pengaturan akun dan API Digiflazz"
+	digiflazzActionContact       = "Hubungi support Digiflazz"
+)
+
+// DigiflazzRC describes a Digiflazz response code.
+type DigiflazzRC struct {
+	Code     string
+	Message  string
+	Category DigiflazzRCCategory
+	Action   string
+}
+
+var digiflazzRCs = map[string]*DigiflazzRC{
+	"00": {Message: "Transaksi sukses", Category: DigiflazzRCSuccess, Action: digiflazzActionNone},
+	"01": {Message: "Timeout", Category: DigiflazzRCRetryable, Action: digiflazzActionRetry},
+	"02": {Message: "Transaksi gagal", Category: DigiflazzRCFailed, Action: digiflazzActionChangeSeller},
+	"03": {Message: "Transaksi pending", Category: DigiflazzRCPending, Action: digiflazzActionWait},
+	"40": {Message: "Payload error", Category: DigiflazzRCConfigError, Action: digiflazzActionContact},
+	"41": {Message: "Signature tidak valid", Category: DigiflazzRCConfigError, Action: digiflazzActionCheckSettings},
+	"42": {Message: "Gagal memproses API buyer", Category: DigiflazzRCConfigError, Action: digiflazzActionCheckSettings},
+	"43": {Message: "SKU tidak ditemukan atau non-aktif", Category: DigiflazzRCConfigError, Action: "Perbarui daftar produk atau ganti produk"},
+	"44": {Message: "Saldo tidak cukup", Category: DigiflazzRCConfigError, Action: digiflazzActionTopUp},
+	"45": {Message: "IP tidak dikenali", Category: DigiflazzRCConfigError, Action: "Daftarkan IP server di pengaturan Digiflazz"},
+	"47": {Message: "Transaksi sudah terjadi di buyer lain", Category: DigiflazzRCFailed, Action: digiflazzActionContact},
+	"49": {Message: "Ref ID tidak unik", Category: DigiflazzRCConfigError, Action: digiflazzActionContact},
+	"50": {Message: "Transaksi tidak ditemukan", Category: DigiflazzRCFailed, Action: digiflazzActionContact},
+	"51": {Message: "Nomor tujuan diblokir", Category: DigiflazzRCFailed, Action: digiflazzActionCheckNumber},
+	"52": {Message: "Prefix tidak sesuai operator", Category: DigiflazzRCFailed, Action: digiflazzActionCheckNumber},
+	"53": {Message: "Produk seller sedang tidak tersedia", Category: DigiflazzRCRetryable, Action: digiflazzActionChangeSeller},
+	"54": {Message: "Nomor tujuan salah", Category: DigiflazzRCFailed, Action: digiflazzActionCheckNumber},
+	"55": {Message: "Produk sedang gangguan", Category: DigiflazzRCRetryable, Action: digiflazzActionChangeSeller},
+	"56": {Message: "Limit saldo seller", Category: DigiflazzRCRetryable, Action: digiflazzActionChangeSeller},
+	"57": {Message: "Jumlah digit kurang atau lebih", Category: DigiflazzRCFailed, Action: digiflazzActionCheckNumber},
+	"58": {Message: "Sedang cut off", Category: DigiflazzRCRetryable, Action: digiflazzActionChangeSeller},
+	"59": {Message: "Tujuan di luar wilayah/cluster", Category: DigiflazzRCFailed, Action: "Ganti produk sesuai wilayah tujuan"},
+	"60": {Message: "Tagihan belum tersedia", Category: DigiflazzRCFailed, Action: digiflazzActionNone},
+	"61": {Message: "Belum pernah melakukan deposit", Category: DigiflazzRCConfigError, Action: digiflazzActionTopUp},
+	"62": {Message: "Seller sedang mengalami gangguan", Category: DigiflazzRCRetryable, Action: digiflazzActionChangeSeller},
+	"63": {Message: "Tidak support transaksi multi", Category: DigiflazzRCRetryable, Action: digiflazzActionChangeSeller},
+	"64": {Message: "Tarik tiket gagal", Category: DigiflazzRCRetryable, Action: "Coba nominal lain atau hubungi support Digiflazz"},
+	"65": {Message: "Limit transaksi multi", Category: DigiflazzRCRetryable, Action: digiflazzActionChangeSeller},
+	"66": {Message: "Cut off (perbaikan sistem seller)", Category: DigiflazzRCRetryable, Action: digiflazzActionChangeSeller},
+	"67": {Message: "Seller belum terverifikasi", Category: DigiflazzRCRetryable, Action: digiflazzActionChangeSeller},
+	"68": {Message: "Stok habis", Category: DigiflazzRCRetryable, Action: digiflazzActionChangeSeller},
+	"69": {Message: "Harga seller lebih besar dari ketentuan harga buyer", Category: DigiflazzRCRetryable, Action: "Naikkan batas harga maksimal di Digiflazz atau ganti seller"},
+	"70": {Message: "Timeout dari biller", Category: DigiflazzRCRetryable, Action: digiflazzActionRetry},
+	"71": {Message: "Produk sedang tidak stabil", Category: DigiflazzRCRetryable, Action: digiflazzActionChangeSeller},
+	"72": {Message: "Lakukan unreg paket dahulu", Category: DigiflazzRCFailed, Action: "Minta pelanggan unreg paket lalu coba lagi"},
+	"73": {Message: "kWh melebihi batas", Category: DigiflazzRCFailed, Action: "Coba nominal lebih kecil"},
+	"74": {Message: "Transaksi refund", Category: DigiflazzRCFailed, Action: digiflazzActionNone},
+	"80": {Message: "Akun diblokir oleh seller", Category: DigiflazzRCRetryable, Action: digiflazzActionChangeSeller},
+	"81": {Message: "Seller diblokir oleh akun ini", Category: DigiflazzRCConfigError, Action: "Buka blokir seller atau ganti seller"},
+	"82": {Message: "Akun belum terverifikasi", Category: DigiflazzRCConfigError, Action: "Selesaikan verifikasi akun Digiflazz"},
+	"83": {Message: "Limitasi pricelist", Category: DigiflazzRCRetryable, Action: digiflazzActionRetry},
+	"84": {Message: "Nominal tidak valid", Category: DigiflazzRCFailed, Action: "Periksa nominal"},
+	"85": {Message: "Limitasi transaksi", Category: DigiflazzRCRetryable, Action: digiflazzActionRetry},
+	"86": {Message: "Limitasi pengecekan nomor PLN", Category: DigiflazzRCRetryable, Action: digiflazzActionRetry},
+	"99": {Message: "DF router issue (pending)", Category: DigiflazzRCPending, Action: digiflazzActionWait},
+}
+
+func init() {
+	for code, rc := range digiflazzRCs {
+		rc.Code = code
+	}
+}
+
+// LookupDigiflazzRC returns the description of a response code.
+// Unknown codes are treated as final failures.
+func LookupDigiflazzRC(code string) *DigiflazzRC {
+	if rc, ok := digiflazzRCs[code]; ok {
+		return rc
+	}
+	return &DigiflazzRC{
+		Code:     code,
+		Message:  "Kode tidak dikenal",
+		Category: DigiflazzRCFailed,
+		Action:   digiflazzActionContact,
+	}
+}
+
+func (rc *DigiflazzRC) String() string {
+	return fmt.Sprintf("RC %s (%s): %s", rc.Code, rc.Category, rc.Message)
+}
+
+// Note is the explanation shown to staff, e.g. "Kode 44: Saldo tidak cukup. Saran: Isi saldo deposit Digiflazz".
+func (rc *DigiflazzRC) Note() string {
+	if rc.Action == "" {
+		return fmt.Sprintf("Kode %s: %s", rc.Code, rc.Message)
+	}
+	return fmt.Sprintf("Kode %s: %s. Saran: %s", rc.Code, rc.Message, rc.Action)
+}
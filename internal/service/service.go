@@ -0,0 +1,28 @@
+package service
+
+import (
+	"github.com/fidrasofyan/digiflazz-bot/internal/config"
+)
+
+// MustLoadClients creates the API clients from the config.
+func MustLoadClients() {
+	Telegram = NewTelegramClient(
+		config.Cfg.TelegramApiBaseUrl,
+		config.Cfg.TelegramBotToken,
+		int(config.Cfg.TelegramMaxRetries),
+	)
+	Digiflazz = NewDigiflazzClient(&DigiflazzClientParams{
+		BaseUrl:  config.Cfg.DigiflazzBaseUrl,
+		Username: config.Cfg.DigiflazzUsername,
+		ApiKey:   config.Cfg.DigiflazzApiKey,
+		Testing:  config.Cfg.AppEnv != "production",
+		Timeouts: DefaultDigiflazzTimeouts,
+	})
+	if config.Cfg.WhatsAppPhoneNumberId != "" {
+		WhatsApp = NewWhatsAppClient(
+			config.Cfg.WhatsAppApiBaseUrl,
+			config.Cfg.WhatsAppPhoneNumberId,
+			config.Cfg.WhatsAppAccessToken,
+		)
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
-	"github.com/fidrasofyan/digiflazz-bot/internal/config"
 	"github.com/fidrasofyan/digiflazz-bot/internal/types"
 )
 
@@ -20,15 +24,27 @@ const (
 	TelegramParseModeMarkdownV2 telegramParseMode = "MarkdownV2"
 )
 
-type TelegramSendMessageParams struct {
-	ChatId             int64                             `json:"chat_id"`
-	ParseMode          telegramParseMode                 `json:"parse_mode"`
-	Text               string                            `json:"text"`
-	LinkPreviewOptions *types.TelegramLinkPreviewOptions `json:"link_preview_options,omitempty"`
+// Long polling needs a client that outlives the poll timeout
+const telegramPollingTimeout = 30 * time.Second
+
+// Longest retry_after we are willing to wait for before giving up
+const telegramMaxRetryAfter = 60 * time.Second
+
+// Telegram is the client used by the bot, set by MustLoadClients.
+var Telegram *TelegramClient
+
+// TelegramClient calls the Telegram Bot API. Failed calls return a *TelegramError.
+// Rate limited (429) and server errors (5xx) are retried.
+type TelegramClient struct {
+	baseUrl           string
+	token             string
+	maxRetries        int
+	httpClient        *http.Client
+	pollingHttpClient *http.Client
 }
 
-var telegramHttpClient = &http.Client{
-	Transport: &http.Transport{
+func NewTelegramClient(baseUrl, token string, maxRetries int) *TelegramClient {
+	transport := &http.Transport{
 		DialContext: (&net.Dialer{
 			Timeout:   5 * time.Second,
 			KeepAlive: 30 * time.Second,
@@ -38,123 +54,426 @@ var telegramHttpClient = &http.Client{
 		MaxIdleConnsPerHost: 100,
 		IdleConnTimeout:     90 * time.Second,
 		TLSHandshakeTimeout: 5 * time.Second,
-	},
-	Timeout: 10 * time.Second,
+	}
+
+	return &TelegramClient{
+		baseUrl:    baseUrl,
+		token:      token,
+		maxRetries: maxRetries,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   10 * time.Second,
+		},
+		pollingHttpClient: &http.Client{
+			Transport: transport,
+			Timeout:   telegramPollingTimeout + 10*time.Second,
+		},
+	}
+}
+
+// Errors matched by TelegramError, e.g. errors.Is(err, service.ErrTelegramForbidden)
+var (
+	ErrTelegramBadRequest      = errors.New("telegram: bad request")
+	ErrTelegramUnauthorized    = errors.New("telegram: unauthorized")
+	ErrTelegramForbidden       = errors.New("telegram: forbidden") // e.g. the user blocked the bot
+	ErrTelegramNotFound        = errors.New("telegram: not found")
+	ErrTelegramTooManyRequests = errors.New("telegram: too many requests")
+)
+
+// TelegramError is an {"ok":false} response.
+type TelegramError struct {
+	Method      string
+	StatusCode  int
+	ErrorCode   int
+	Description string
+	// RetryAfter is set when the request was rate limited
+	RetryAfter time.Duration
 }
 
-func TelegramSendMessage(ctx context.Context, params *TelegramSendMessageParams) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", config.Cfg.TelegramBotToken)
+func (e *TelegramError) Error() string {
+	return fmt.Sprintf("telegram %s: %d %s", e.Method, e.ErrorCode, e.Description)
+}
+
+func (e *TelegramError) Is(target error) bool {
+	switch e.ErrorCode {
+	case http.StatusBadRequest:
+		return target == ErrTelegramBadRequest
+	case http.StatusUnauthorized:
+		return target == ErrTelegramUnauthorized
+	case http.StatusForbidden:
+		return target == ErrTelegramForbidden
+	case http.StatusNotFound:
+		return target == ErrTelegramNotFound
+	case http.StatusTooManyRequests:
+		return target == ErrTelegramTooManyRequests
+	}
+	return false
+}
+
+func (e *TelegramError) retryable() bool {
+	return e.ErrorCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+type telegramResponse struct {
+	Ok          bool            `json:"ok"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+	Parameters  *struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// telegramRequest is a request body that can be sent more than once.
+type telegramRequest struct {
+	contentType string
+	body        []byte
+	httpClient  *http.Client
+}
+
+// call sends params as JSON and decodes the result into result (if not nil).
+func (c *TelegramClient) call(ctx context.Context, method string, params any, result any) error {
 	jsonData, err := json.Marshal(params)
 	if err != nil {
 		return err
 	}
+	return c.do(ctx, method, &telegramRequest{
+		contentType: "application/json",
+		body:        jsonData,
+		httpClient:  c.httpClient,
+	}, result)
+}
+
+func (c *TelegramClient) do(ctx context.Context, method string, tgReq *telegramRequest, result any) error {
+	url := fmt.Sprintf("%s/bot%s/%s", c.baseUrl, c.token, method)
+
+	for attempt := 0; ; attempt++ {
+		err := c.doOnce(ctx, url, method, tgReq, result)
+
+		var tgErr *TelegramError
+		if err == nil || !errors.As(err, &tgErr) || !tgErr.retryable() || attempt >= c.maxRetries {
+			return err
+		}
+
+		// Wait as long as Telegram asks, otherwise back off
+		wait := tgErr.RetryAfter
+		if wait == 0 {
+			wait = time.Duration(attempt+1) * time.Second
+		}
+		if wait > telegramMaxRetryAfter {
+			return err
+		}
+		log.Printf("Telegram %s: retrying in %s: %v", method, wait, err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+func (c *TelegramClient) doOnce(ctx context.Context, url, method string, tgReq *telegramRequest, result any) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(tgReq.body))
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", tgReq.contentType)
 
-	res, err := telegramHttpClient.Do(req)
+	res, err := tgReq.httpClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
 
-	return nil
+	var response telegramResponse
+	err = json.NewDecoder(res.Body).Decode(&response)
+	if err != nil {
+		if res.StatusCode != http.StatusOK {
+			return &TelegramError{
+				Method:      method,
+				StatusCode:  res.StatusCode,
+				ErrorCode:   res.StatusCode,
+				Description: http.StatusText(res.StatusCode),
+			}
+		}
+		return err
+	}
+
+	if !response.Ok {
+		tgErr := &TelegramError{
+			Method:      method,
+			StatusCode:  res.StatusCode,
+			ErrorCode:   response.ErrorCode,
+			Description: response.Description,
+		}
+		if response.Parameters != nil {
+			tgErr.RetryAfter = time.Duration(response.Parameters.RetryAfter) * time.Second
+		}
+		return tgErr
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Result, result)
 }
 
-type TelegramAnswerCallbackQueryParams struct {
-	CallbackQueryId string  `json:"callback_query_id"`
-	Text            *string `json:"text,omitempty"`
-	ShowAlert       *bool   `json:"show_alert,omitempty"`
+// TelegramInputFile is a file uploaded with multipart/form-data.
+type TelegramInputFile struct {
+	Name string
+	Data []byte
 }
 
-func TelegramAnswerCallbackQuery(ctx context.Context, params *TelegramAnswerCallbackQueryParams) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/answerCallbackQuery", config.Cfg.TelegramBotToken)
-	jsonData, err := json.Marshal(params)
+// upload sends fields and a file as multipart/form-data. Non-string fields are JSON encoded.
+func (c *TelegramClient) upload(ctx context.Context, method string, fields map[string]any, fileField string, file *TelegramInputFile, result any) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for key, value := range fields {
+		var str string
+		switch v := value.(type) {
+		case nil:
+			continue
+		case string:
+			if v == "" {
+				continue
+			}
+			str = v
+		case int64:
+			str = strconv.FormatInt(v, 10)
+		default:
+			jsonData, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			str = string(jsonData)
+		}
+		err := writer.WriteField(key, str)
+		if err != nil {
+			return err
+		}
+	}
+
+	part, err := writer.CreateFormFile(fileField, file.Name)
 	if err != nil {
 		return err
 	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	_, err = io.Copy(part, bytes.NewReader(file.Data))
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	res, err := telegramHttpClient.Do(req)
+	err = writer.Close()
 	if err != nil {
 		return err
 	}
-	defer res.Body.Close()
 
-	return nil
+	return c.do(ctx, method, &telegramRequest{
+		contentType: writer.FormDataContentType(),
+		body:        body.Bytes(),
+		httpClient:  c.httpClient,
+	}, result)
 }
 
-func TelegramSetWebhook(ctx context.Context) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/setWebhook", config.Cfg.TelegramBotToken)
-	data := struct {
-		Url                string   `json:"url"`
-		SecretToken        string   `json:"secret_token"`
-		MaxConnections     int      `json:"max_connections"`
-		DropPendingUpdates bool     `json:"drop_pending_updates"`
-		AllowedUpdates     []string `json:"allowed_updates"`
-	}{
-		Url:                config.Cfg.WebhookURL + "/telegram",
-		SecretToken:        config.Cfg.TelegramWebhookSecretToken,
-		MaxConnections:     50,
-		DropPendingUpdates: true,
-		AllowedUpdates:     []string{"message", "callback_query"},
+// Send message
+type TelegramSendMessageParams struct {
+	ChatId             int64                             `json:"chat_id"`
+	ParseMode          telegramParseMode                 `json:"parse_mode"`
+	Text               string                            `json:"text"`
+	LinkPreviewOptions *types.TelegramLinkPreviewOptions `json:"link_preview_options,omitempty"`
+	ReplyMarkup        any                               `json:"reply_markup,omitempty"`
+}
+
+func (c *TelegramClient) SendMessage(ctx context.Context, params *TelegramSendMessageParams) (*types.TelegramMessage, error) {
+	var message types.TelegramMessage
+	err := c.call(ctx, "sendMessage", params, &message)
+	if err != nil {
+		return nil, err
 	}
-	jsonData, err := json.Marshal(data)
+	return &message, nil
+}
+
+// Edit message text
+type TelegramEditMessageTextParams struct {
+	ChatId      int64             `json:"chat_id"`
+	MessageId   int64             `json:"message_id"`
+	ParseMode   telegramParseMode `json:"parse_mode"`
+	Text        string            `json:"text"`
+	ReplyMarkup any               `json:"reply_markup,omitempty"`
+}
+
+func (c *TelegramClient) EditMessageText(ctx context.Context, params *TelegramEditMessageTextParams) error {
+	return c.call(ctx, "editMessageText", params, nil)
+}
+
+// Answer callback query
+type TelegramAnswerCallbackQueryParams struct {
+	CallbackQueryId string  `json:"callback_query_id"`
+	Text            *string `json:"text,omitempty"`
+	ShowAlert       *bool   `json:"show_alert,omitempty"`
+}
+
+func (c *TelegramClient) AnswerCallbackQuery(ctx context.Context, params *TelegramAnswerCallbackQueryParams) error {
+	// Callbacks from other channels, e.g. WhatsApp buttons, have nothing to answer
+	if params.CallbackQueryId == "" {
+		return nil
+	}
+	return c.call(ctx, "answerCallbackQuery", params, nil)
+}
+
+// Delete message
+type TelegramDeleteMessageParams struct {
+	ChatId    int64 `json:"chat_id"`
+	MessageId int64 `json:"message_id"`
+}
+
+func (c *TelegramClient) DeleteMessage(ctx context.Context, params *TelegramDeleteMessageParams) error {
+	return c.call(ctx, "deleteMessage", params, nil)
+}
+
+// Send document
+type TelegramSendDocumentParams struct {
+	ChatId      int64
+	Document    *TelegramInputFile
+	Caption     string
+	ParseMode   telegramParseMode
+	ReplyMarkup any
+}
+
+func (c *TelegramClient) SendDocument(ctx context.Context, params *TelegramSendDocumentParams) (*types.TelegramMessage, error) {
+	var message types.TelegramMessage
+	err := c.upload(ctx, "sendDocument", map[string]any{
+		"chat_id":      params.ChatId,
+		"caption":      params.Caption,
+		"parse_mode":   string(params.ParseMode),
+		"reply_markup": params.ReplyMarkup,
+	}, "document", params.Document, &message)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return &message, nil
+}
+
+// Send photo
+type TelegramSendPhotoParams struct {
+	ChatId      int64
+	Photo       *TelegramInputFile
+	Caption     string
+	ParseMode   telegramParseMode
+	ReplyMarkup any
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+func (c *TelegramClient) SendPhoto(ctx context.Context, params *TelegramSendPhotoParams) (*types.TelegramMessage, error) {
+	var message types.TelegramMessage
+	err := c.upload(ctx, "sendPhoto", map[string]any{
+		"chat_id":      params.ChatId,
+		"caption":      params.Caption,
+		"parse_mode":   string(params.ParseMode),
+		"reply_markup": params.ReplyMarkup,
+	}, "photo", params.Photo, &message)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return &message, nil
+}
+
+// Set webhook
+type TelegramSetWebhookParams struct {
+	Url                string   `json:"url"`
+	SecretToken        string   `json:"secret_token"`
+	MaxConnections     int      `json:"max_connections"`
+	DropPendingUpdates bool     `json:"drop_pending_updates"`
+	AllowedUpdates     []string `json:"allowed_updates"`
+}
+
+func (c *TelegramClient) SetWebhook(ctx context.Context, params *TelegramSetWebhookParams) error {
+	return c.call(ctx, "setWebhook", params, nil)
+}
 
-	res, err := telegramHttpClient.Do(req)
+// Get webhook info
+type TelegramWebhookInfo struct {
+	Url                  string   `json:"url"`
+	HasCustomCertificate bool     `json:"has_custom_certificate"`
+	PendingUpdateCount   int64    `json:"pending_update_count"`
+	LastErrorDate        int64    `json:"last_error_date"`
+	LastErrorMessage     string   `json:"last_error_message"`
+	MaxConnections       int      `json:"max_connections"`
+	AllowedUpdates       []string `json:"allowed_updates"`
+}
+
+func (c *TelegramClient) GetWebhookInfo(ctx context.Context) (*TelegramWebhookInfo, error) {
+	var info TelegramWebhookInfo
+	err := c.call(ctx, "getWebhookInfo", struct{}{}, &info)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer res.Body.Close()
+	return &info, nil
+}
 
-	return nil
+// Delete webhook
+type TelegramDeleteWebhookParams struct {
+	DropPendingUpdates bool `json:"drop_pending_updates"`
 }
 
+func (c *TelegramClient) DeleteWebhook(ctx context.Context, params *TelegramDeleteWebhookParams) error {
+	return c.call(ctx, "deleteWebhook", params, nil)
+}
+
+// Set my commands
 type TelegramCommand struct {
 	Command     string `json:"command"`
 	Description string `json:"description"`
 }
 
-func TelegramSetMyCommands(ctx context.Context, commands []TelegramCommand) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/setMyCommands", config.Cfg.TelegramBotToken)
+// TelegramBotCommandScope limits commands to a group of chats.
+// Type is "default", "all_private_chats" or "chat" (with ChatId).
+type TelegramBotCommandScope struct {
+	Type   string `json:"type"`
+	ChatId int64  `json:"chat_id,omitempty"`
+}
+
+type TelegramSetMyCommandsParams struct {
+	Commands []TelegramCommand        `json:"commands"`
+	Scope    *TelegramBotCommandScope `json:"scope,omitempty"`
+}
+
+func (c *TelegramClient) SetMyCommands(ctx context.Context, params *TelegramSetMyCommandsParams) error {
+	if params.Commands == nil {
+		params.Commands = []TelegramCommand{}
+	}
+	return c.call(ctx, "setMyCommands", params, nil)
+}
+
+// Get updates
+func (c *TelegramClient) GetUpdates(ctx context.Context, offset int64) ([]types.TelegramUpdate, error) {
 	data := struct {
-		Commands []TelegramCommand `json:"commands"`
+		Offset         int64    `json:"offset"`
+		Timeout        int      `json:"timeout"`
+		AllowedUpdates []string `json:"allowed_updates"`
 	}{
-		Commands: commands,
+		Offset:         offset,
+		Timeout:        int(telegramPollingTimeout.Seconds()),
+		AllowedUpdates: []string{"message", "callback_query"},
 	}
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	res, err := telegramHttpClient.Do(req)
+	var updates []types.TelegramUpdate
+	err = c.do(ctx, "getUpdates", &telegramRequest{
+		contentType: "application/json",
+		body:        jsonData,
+		httpClient:  c.pollingHttpClient,
+	}, &updates)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer res.Body.Close()
+	return updates, nil
+}
 
-	return nil
+// SendResponse calls the method of a webhook-style response.
+// Used when there is no webhook request to reply to, e.g. in long polling mode.
+func (c *TelegramClient) SendResponse(ctx context.Context, resp *types.TelegramResponse) error {
+	return c.call(ctx, string(resp.Method), resp, nil)
 }
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"time"
+)
+
+// WhatsApp is the client of the WhatsApp Cloud API, set by MustLoadClients
+// when WHATSAPP_PHONE_NUMBER_ID is configured.
+var WhatsApp *WhatsAppClient
+
+// WhatsAppClient sends messages from one business phone number. Failed calls
+// return a *WhatsAppError.
+type WhatsAppClient struct {
+	baseUrl       string
+	phoneNumberId string
+	accessToken   string
+	httpClient    *http.Client
+}
+
+func NewWhatsAppClient(baseUrl, phoneNumberId, accessToken string) *WhatsAppClient {
+	return &WhatsAppClient{
+		baseUrl:       baseUrl,
+		phoneNumberId: phoneNumberId,
+		accessToken:   accessToken,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// WhatsAppError is an {"error":{...}} response of the Graph API.
+type WhatsAppError struct {
+	Path       string
+	StatusCode int
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *WhatsAppError) Error() string {
+	return fmt.Sprintf("whatsapp %s: %d %s", e.Path, e.Code, e.Message)
+}
+
+// do sends the request and decodes the response into result (if not nil).
+func (c *WhatsAppClient) do(ctx context.Context, path, contentType string, body []byte, result any) error {
+	url := fmt.Sprintf("%s/%s/%s", c.baseUrl, c.phoneNumberId, path)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		var response struct {
+			Error *WhatsAppError `json:"error"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&response); err != nil || response.Error == nil {
+			return &WhatsAppError{
+				Path:       path,
+				StatusCode: res.StatusCode,
+				Code:       res.StatusCode,
+				Message:    http.StatusText(res.StatusCode),
+			}
+		}
+		response.Error.Path = path
+		response.Error.StatusCode = res.StatusCode
+		return response.Error
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(result)
+}
+
+type WhatsAppText struct {
+	Body       string `json:"body"`
+	PreviewUrl bool   `json:"preview_url"`
+}
+
+type WhatsAppMedia struct {
+	Id       string `json:"id"`
+	Caption  string `json:"caption,omitempty"`
+	Filename string `json:"filename,omitempty"`
+}
+
+// WhatsAppInteractive is a message with reply buttons (up to 3) or a list (up to 10 rows).
+type WhatsAppInteractive struct {
+	Type   string                    `json:"type"`
+	Body   WhatsAppInteractiveBody   `json:"body"`
+	Action WhatsAppInteractiveAction `json:"action"`
+}
+
+type WhatsAppInteractiveBody struct {
+	Text string `json:"text"`
+}
+
+type WhatsAppInteractiveAction struct {
+	Buttons  []WhatsAppButton  `json:"buttons,omitempty"`
+	Button   string            `json:"button,omitempty"`
+	Sections []WhatsAppSection `json:"sections,omitempty"`
+}
+
+type WhatsAppButton struct {
+	Type  string `json:"type"`
+	Reply struct {
+		Id    string `json:"id"`
+		Title string `json:"title"`
+	} `json:"reply"`
+}
+
+type WhatsAppSection struct {
+	Title string        `json:"title,omitempty"`
+	Rows  []WhatsAppRow `json:"rows"`
+}
+
+type WhatsAppRow struct {
+	Id          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// Send message
+type WhatsAppSendMessageParams struct {
+	To          string               `json:"to"`
+	Type        string               `json:"type"`
+	Text        *WhatsAppText        `json:"text,omitempty"`
+	Interactive *WhatsAppInteractive `json:"interactive,omitempty"`
+	Image       *WhatsAppMedia       `json:"image,omitempty"`
+	Document    *WhatsAppMedia       `json:"document,omitempty"`
+}
+
+func (c *WhatsAppClient) SendMessage(ctx context.Context, params *WhatsAppSendMessageParams) error {
+	jsonData, err := json.Marshal(struct {
+		MessagingProduct string `json:"messaging_product"`
+		RecipientType    string `json:"recipient_type"`
+		*WhatsAppSendMessageParams
+	}{"whatsapp", "individual", params})
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, "messages", "application/json", jsonData, nil)
+}
+
+// UploadMedia uploads a file to be sent by its ID, and returns the ID.
+func (c *WhatsAppClient) UploadMedia(ctx context.Context, name, mimeType string, data []byte) (string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for key, value := range map[string]string{"messaging_product": "whatsapp", "type": mimeType} {
+		if err := writer.WriteField(key, value); err != nil {
+			return "", err
+		}
+	}
+
+	// The Graph API wants the MIME type on the file part too
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, name))
+	header.Set("Content-Type", mimeType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(part, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	err = writer.Close()
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Id string `json:"id"`
+	}
+	err = c.do(ctx, "media", writer.FormDataContentType(), body.Bytes(), &result)
+	if err != nil {
+		return "", err
+	}
+	return result.Id, nil
+}
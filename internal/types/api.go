@@ -0,0 +1,60 @@
+package types
+
+import "time"
+
+type APIProduct struct {
+	Code           string  `json:"code"`
+	Name           string  `json:"name"`
+	Category       string  `json:"category"`
+	Brand          string  `json:"brand"`
+	Type           string  `json:"type"`
+	Seller         string  `json:"seller"`
+	Price          int64   `json:"price"`
+	Active         bool    `json:"active"`
+	UnlimitedStock bool    `json:"unlimited_stock"`
+	Stock          int64   `json:"stock"`
+	Multi          bool    `json:"multi"`
+	StartCutOff    *string `json:"start_cut_off"`
+	EndCutOff      *string `json:"end_cut_off"`
+	Description    *string `json:"description"`
+}
+
+type APITransaction struct {
+	RefID       string    `json:"ref_id"`
+	ChatID      int64     `json:"chat_id"`
+	ProductCode string    `json:"product_code"`
+	CustomerNo  string    `json:"customer_no"`
+	Price       int64     `json:"price"`
+	Status      string    `json:"status"`
+	RC          *string   `json:"rc"`
+	SN          *string   `json:"sn"`
+	Message     *string   `json:"message"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type APICreateTransactionRequest struct {
+	ProductCode string `json:"product_code"`
+	CustomerNo  string `json:"customer_no"`
+	// AllowDuplicate sends it even if the same product was sent to the same number recently
+	AllowDuplicate bool `json:"allow_duplicate"`
+}
+
+type APIBalance struct {
+	Deposit int64 `json:"deposit"`
+}
+
+type APIUser struct {
+	ChatID        int64      `json:"chat_id"`
+	Username      *string    `json:"username"`
+	FirstName     *string    `json:"first_name"`
+	LastName      *string    `json:"last_name"`
+	Role          string     `json:"role"`
+	WalletBalance int64      `json:"wallet_balance"`
+	CreatedAt     *time.Time `json:"created_at"`
+}
+
+type APISetRoleRequest struct {
+	// Role is a role name, or "default" for the role from the config
+	Role string `json:"role"`
+}
@@ -0,0 +1,66 @@
+package types
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DigiflazzPLNToken is the SN of a PLN prepaid transaction,
+// e.g. "1234-5678-9012-3456-7890/BUDI SANTOSO/R1/900VA/13.5".
+type DigiflazzPLNToken struct {
+	// Token is the 20-digit token, without separators
+	Token        string
+	CustomerName string
+	Tariff       string
+	Power        string
+	KWh          string
+}
+
+// ParseDigiflazzPLNToken parses a PLN prepaid SN. It returns false for other SNs.
+// Sellers may leave out the fields after the token.
+func ParseDigiflazzPLNToken(sn string) (*DigiflazzPLNToken, bool) {
+	parts := strings.Split(sn, "/")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	token := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, parts[0])
+	if len(token) != 20 || strings.IndexFunc(token, func(r rune) bool { return !unicode.IsDigit(r) }) != -1 {
+		return nil, false
+	}
+
+	field := func(i int) string {
+		if i < len(parts) {
+			return parts[i]
+		}
+		return ""
+	}
+	return &DigiflazzPLNToken{
+		Token:        token,
+		CustomerName: field(1),
+		Tariff:       field(2),
+		Power:        field(3),
+		KWh:          field(4),
+	}, true
+}
+
+// TariffPower is the tariff and power, e.g. "R1/900VA".
+func (t *DigiflazzPLNToken) TariffPower() string {
+	power := t.Power
+	if power != "" && strings.IndexFunc(power, func(r rune) bool { return !unicode.IsDigit(r) }) == -1 {
+		power += "VA"
+	}
+	switch {
+	case t.Tariff != "" && power != "":
+		return t.Tariff + "/" + power
+	case t.Tariff != "":
+		return t.Tariff
+	default:
+		return power
+	}
+}
@@ -0,0 +1,89 @@
+package types
+
+import "testing"
+
+func TestParseDigiflazzPLNToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		sn          string
+		want        *DigiflazzPLNToken
+		tariffPower string
+	}{
+		{
+			name: "full SN",
+			sn:   "1234-5678-9012-3456-7890/BUDI SANTOSO/R1/900VA/13.5",
+			want: &DigiflazzPLNToken{
+				Token:        "12345678901234567890",
+				CustomerName: "BUDI SANTOSO",
+				Tariff:       "R1",
+				Power:        "900VA",
+				KWh:          "13.5",
+			},
+			tariffPower: "R1/900VA",
+		},
+		{
+			name: "power without unit",
+			sn:   "1234 5678 9012 3456 7890 / BUDI / R1M / 1300 / 20,1",
+			want: &DigiflazzPLNToken{
+				Token:        "12345678901234567890",
+				CustomerName: "BUDI",
+				Tariff:       "R1M",
+				Power:        "1300",
+				KWh:          "20,1",
+			},
+			tariffPower: "R1M/1300VA",
+		},
+		{
+			name:        "token only",
+			sn:          "12345678901234567890",
+			want:        &DigiflazzPLNToken{Token: "12345678901234567890"},
+			tariffPower: "",
+		},
+		{
+			name: "tariff without power",
+			sn:   "12345678901234567890/BUDI/R1",
+			want: &DigiflazzPLNToken{
+				Token:        "12345678901234567890",
+				CustomerName: "BUDI",
+				Tariff:       "R1",
+			},
+			tariffPower: "R1",
+		},
+		{
+			name: "power without tariff",
+			sn:   "12345678901234567890/BUDI//900",
+			want: &DigiflazzPLNToken{
+				Token:        "12345678901234567890",
+				CustomerName: "BUDI",
+				Power:        "900",
+			},
+			tariffPower: "900VA",
+		},
+		{name: "short token", sn: "1234-5678-9012-3456/BUDI/R1/900VA/13.5"},
+		{name: "long token", sn: "1234-5678-9012-3456-7890-1/BUDI"},
+		{name: "letters in token", sn: "1234-5678-9012-3456-789A/BUDI"},
+		{name: "pulsa SN", sn: "0041001234567890"},
+		{name: "empty SN", sn: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseDigiflazzPLNToken(tt.sn)
+			if tt.want == nil {
+				if ok {
+					t.Fatalf("ParseDigiflazzPLNToken(%q) = %+v, want no token", tt.sn, got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("ParseDigiflazzPLNToken(%q) found no token", tt.sn)
+			}
+			if *got != *tt.want {
+				t.Errorf("ParseDigiflazzPLNToken(%q) = %+v, want %+v", tt.sn, got, tt.want)
+			}
+			if tp := got.TariffPower(); tp != tt.tariffPower {
+				t.Errorf("TariffPower() = %q, want %q", tp, tt.tariffPower)
+			}
+		})
+	}
+}
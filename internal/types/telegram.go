@@ -3,8 +3,9 @@ package types
 type telegramMethod string
 
 const (
-	TelegramMethodSendMessage     telegramMethod = "sendMessage"
-	TelegramMethodEditMessageText telegramMethod = "editMessageText"
+	TelegramMethodSendMessage         telegramMethod = "sendMessage"
+	TelegramMethodEditMessageText     telegramMethod = "editMessageText"
+	TelegramMethodAnswerCallbackQuery telegramMethod = "answerCallbackQuery"
 )
 
 type telegramParseMode string
@@ -28,6 +29,7 @@ type TelegramResponse struct {
 	Text               string                      `json:"text,omitempty"`
 	ReplyMarkup        any                         `json:"reply_markup,omitempty"`
 	LinkPreviewOptions *TelegramLinkPreviewOptions `json:"link_preview_options,omitempty"`
+	CallbackQueryId    string                      `json:"callback_query_id,omitempty"`
 }
 
 type TelegramMessage struct {
@@ -66,6 +68,10 @@ type TelegramReplyKeyboardMarkup struct {
 	ResizeKeyboard bool       `json:"resize_keyboard"`
 }
 
+type TelegramReplyKeyboardRemove struct {
+	RemoveKeyboard bool `json:"remove_keyboard"`
+}
+
 var DefaultReplyMarkup = TelegramReplyKeyboardMarkup{
 	Keyboard: [][]string{
 		{"Daftar Produk", "Refresh Produk"},
@@ -0,0 +1,50 @@
+package types
+
+// WhatsAppWebhook is the body of a WhatsApp Cloud API webhook.
+type WhatsAppWebhook struct {
+	Object string          `json:"object"`
+	Entry  []WhatsAppEntry `json:"entry"`
+}
+
+type WhatsAppEntry struct {
+	Id      string           `json:"id"`
+	Changes []WhatsAppChange `json:"changes"`
+}
+
+type WhatsAppChange struct {
+	Field string              `json:"field"`
+	Value WhatsAppChangeValue `json:"value"`
+}
+
+type WhatsAppChangeValue struct {
+	MessagingProduct string            `json:"messaging_product"`
+	Contacts         []WhatsAppContact `json:"contacts"`
+	Messages         []WhatsAppMessage `json:"messages"`
+}
+
+type WhatsAppContact struct {
+	WaId    string `json:"wa_id"`
+	Profile struct {
+		Name string `json:"name"`
+	} `json:"profile"`
+}
+
+type WhatsAppMessage struct {
+	Id        string `json:"id"`
+	From      string `json:"from"`
+	Timestamp string `json:"timestamp"`
+	Type      string `json:"type"`
+	Text      struct {
+		Body string `json:"body"`
+	} `json:"text"`
+	Interactive struct {
+		Type        string              `json:"type"`
+		ButtonReply WhatsAppReplyOption `json:"button_reply"`
+		ListReply   WhatsAppReplyOption `json:"list_reply"`
+	} `json:"interactive"`
+}
+
+type WhatsAppReplyOption struct {
+	Id    string `json:"id"`
+	Title string `json:"title"`
+}
@@ -1,10 +1,15 @@
 package util
 
 import (
+	"crypto/pbkdf2"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 )
 
 func GenerateSecretToken(length int) (string, error) {
@@ -18,3 +23,53 @@ func GenerateSecretToken(length int) (string, error) {
 	// URL-safe ([-_]) and no padding; shorter strings.
 	return base64.RawURLEncoding.EncodeToString(b), nil
 }
+
+const (
+	pinHashIterations = 210_000
+	pinHashKeyLength  = 32
+	pinHashSaltLength = 16
+)
+
+// HashPin hashes a PIN with PBKDF2-SHA256 and a random salt.
+// Format: pbkdf2-sha256$<iterations>$<salt>$<hash>
+func HashPin(pin string) (string, error) {
+	salt := make([]byte, pinHashSaltLength)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", fmt.Errorf("read randomness: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, pin, salt, pinHashIterations, pinHashKeyLength)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(
+		"pbkdf2-sha256$%d$%s$%s",
+		pinHashIterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// VerifyPin reports whether the PIN matches a hash produced by HashPin.
+func VerifyPin(pin string, hash string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, pin, salt, iterations, len(expected))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}